@@ -9,17 +9,76 @@ import (
 
 type Client struct {
 	cli     jsonrpc.Client
+	tcp     *jsonrpc.TCPClient
 	address string
 	timeout time.Duration
+
+	retry    jsonrpc.RetryPolicy
+	breaker  *jsonrpc.CircuitBreaker
+	observer jsonrpc.Observer
+}
+
+func New(addr string, timeout time.Duration, opts jsonrpc.TCPOptions) *Client {
+	return NewWithTCPClient(jsonrpc.NewTCPClientWithOptions(addr, opts), timeout)
 }
 
-func New(addr string, timeout time.Duration) *Client {
+// NewWithTCPClient returns a client calling through tcp, whose connection pool may be shared with other clients
+func NewWithTCPClient(tcp *jsonrpc.TCPClient, timeout time.Duration) *Client {
 	return &Client{
-		cli:     jsonrpc.NewTCPClient(addr),
+		cli:     tcp,
+		tcp:     tcp,
+		address: tcp.Address(),
 		timeout: timeout,
 	}
 }
 
+func (c Client) Address() string {
+	return c.address
+}
+
+// SetResilience retries failed calls by policy and skips calls while breaker is open
+func (c *Client) SetResilience(policy jsonrpc.RetryPolicy, breaker *jsonrpc.CircuitBreaker) {
+	c.retry = policy
+	c.breaker = breaker
+	c.build()
+}
+
+// Breaker returns the circuit breaker of the client, nil if not set
+func (c *Client) Breaker() *jsonrpc.CircuitBreaker {
+	return c.breaker
+}
+
+// SetObserver reports every call, including each retry, to observer
+func (c *Client) SetObserver(observer jsonrpc.Observer) {
+	c.observer = observer
+	c.build()
+}
+
+func (c *Client) build() {
+	var cli jsonrpc.Client = c.tcp
+	if c.observer != nil {
+		cli = jsonrpc.NewObservedClient(cli, c.observer)
+	}
+	if c.breaker != nil || c.retry.MaxAttempts > 1 {
+		cli = jsonrpc.NewResilientClient(cli, c.retry, c.breaker)
+	}
+	c.cli = cli
+}
+
+// TCPClient returns the underlying client, whose connection pool may be shared with other clients
+func (c *Client) TCPClient() *jsonrpc.TCPClient {
+	return c.tcp
+}
+
+// PoolStats returns statistics of the underlying connection pool
+func (c *Client) PoolStats() jsonrpc.PoolStats {
+	return c.tcp.PoolStats()
+}
+
+func (c *Client) Close() error {
+	return c.tcp.Close()
+}
+
 func (c Client) defaultCtx() (context.Context, context.CancelFunc) {
 	if c.timeout == 0 {
 		return context.WithCancel(context.Background())
@@ -56,13 +115,13 @@ func (c *Client) getResp(request *jsonrpc.Request) (*jsonrpc.Response, error) {
 	return resp, err
 }
 
-func (c *Client) CallBatch(req ...*jsonrpc.Request) ([]*jsonrpc.Response, error) {
+func (c *Client) CallBatch(req ...*jsonrpc.Request) (*jsonrpc.BatchResponse, error) {
 	ctx, cancel := c.defaultCtx()
 	defer cancel()
 	return c.CallBatchContext(ctx, req...)
 }
 
-func (c *Client) CallBatchContext(ctx context.Context, req ...*jsonrpc.Request) ([]*jsonrpc.Response, error) {
+func (c *Client) CallBatchContext(ctx context.Context, req ...*jsonrpc.Request) (*jsonrpc.BatchResponse, error) {
 	return c.cli.CallBatchContext(ctx, req...)
 }
 
@@ -118,20 +177,21 @@ func (c *Client) GetNodeTypeContext(ctx context.Context) (NodeType, error) {
 	return nt, err
 }
 
-// TODO: GetDSCommittee
-//func (c *Client) GetDSCommittee() (int64, error) {
-//	ctx, cancel := c.defaultCtx()
-//	defer cancel()
-//	return c.GetCurrentMiniEpochContext(ctx)
-//}
-//
-//func (c *Client) GetDSCommitteeContext(ctx context.Context) (int64, error) {
-//	resp, err := c.getRespContext(ctx, NewGetDSCommitteeReq())
-//	if err != nil {
-//		return 0, err
-//	}
-//	return resp.GetInt64()
-//}
+func (c *Client) GetDSCommittee() (DSCommittee, error) {
+	ctx, cancel := c.defaultCtx()
+	defer cancel()
+	return c.GetDSCommitteeContext(ctx)
+}
+
+func (c *Client) GetDSCommitteeContext(ctx context.Context) (DSCommittee, error) {
+	var committee DSCommittee
+	resp, err := c.getRespContext(ctx, NewGetDSCommitteeReq())
+	if err != nil {
+		return nil, err
+	}
+	err = resp.GetObject(&committee)
+	return committee, err
+}
 
 func (c *Client) GetNodeState() (NodeState, error) {
 	var nt NodeState
@@ -185,4 +245,76 @@ func (c *Client) GetPrevDSDifficultyContext(ctx context.Context) (int64, error)
 	return resp.GetInt64()
 }
 
-// TODO: the rest methods
+func (c *Client) getBool(request *jsonrpc.Request) (bool, error) {
+	ctx, cancel := c.defaultCtx()
+	defer cancel()
+	return c.getBoolContext(ctx, request)
+}
+
+func (c *Client) getBoolContext(ctx context.Context, request *jsonrpc.Request) (bool, error) {
+	var b bool
+	resp, err := c.getRespContext(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	err = resp.GetObject(&b)
+	return b, err
+}
+
+func (c *Client) GetSendSCCallsToDS() (bool, error) {
+	return c.getBool(NewGetSendSCCallsToDSReq())
+}
+
+func (c *Client) GetSendSCCallsToDSContext(ctx context.Context) (bool, error) {
+	return c.getBoolContext(ctx, NewGetSendSCCallsToDSReq())
+}
+
+func (c *Client) IsTxnInMemPool(txn string) (bool, error) {
+	return c.getBool(NewIsTxnInMemPoolReq(txn))
+}
+
+func (c *Client) IsTxnInMemPoolContext(ctx context.Context, txn string) (bool, error) {
+	return c.getBoolContext(ctx, NewIsTxnInMemPoolReq(txn))
+}
+
+// mutating methods, callers should not retry them blindly as toggles are not idempotent
+
+func (c *Client) AddToBlacklistExclusion(ip string) (bool, error) {
+	return c.getBool(NewAddToBlacklistExclusionReq(ip))
+}
+
+func (c *Client) AddToBlacklistExclusionContext(ctx context.Context, ip string) (bool, error) {
+	return c.getBoolContext(ctx, NewAddToBlacklistExclusionReq(ip))
+}
+
+func (c *Client) RemoveFromBlacklistExclusion(ip string) (bool, error) {
+	return c.getBool(NewRemoveFromBlacklistExclusionReq(ip))
+}
+
+func (c *Client) RemoveFromBlacklistExclusionContext(ctx context.Context, ip string) (bool, error) {
+	return c.getBoolContext(ctx, NewRemoveFromBlacklistExclusionReq(ip))
+}
+
+func (c *Client) ToggleSendSCCallsToDS() (bool, error) {
+	return c.getBool(NewToggleSendSCCallsToDSReq())
+}
+
+func (c *Client) ToggleSendSCCallsToDSContext(ctx context.Context) (bool, error) {
+	return c.getBoolContext(ctx, NewToggleSendSCCallsToDSReq())
+}
+
+func (c *Client) DisablePoW() (bool, error) {
+	return c.getBool(NewDisablePoWReq())
+}
+
+func (c *Client) DisablePoWContext(ctx context.Context) (bool, error) {
+	return c.getBoolContext(ctx, NewDisablePoWReq())
+}
+
+func (c *Client) ToggleDisableTxns() (bool, error) {
+	return c.getBool(NewToggleDisableTxnsReq())
+}
+
+func (c *Client) ToggleDisableTxnsContext(ctx context.Context) (bool, error) {
+	return c.getBoolContext(ctx, NewToggleDisableTxnsReq())
+}
@@ -1,61 +1,50 @@
 package adminclient
 
 import (
-	"net"
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
 	"testing"
+	"time"
 )
 
-func testServer(output []byte) (net.Addr, error) {
-	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
-	if err != nil {
-		return nil, err
-	}
-	go func() {
-		defer l.Close()
-		conn, err := l.AcceptTCP()
-		if err != nil {
-			return
-		}
-		defer conn.Close()
-		_, _ = conn.Read(make([]byte, 4096))
-		_, _ = conn.Write(output)
-	}()
-	return l.Addr(), nil
-}
-
 func TestClient(t *testing.T) {
-	//assert := asserting.New(t)
-	//resp := ResponseTypeOfMethod(GetCurrentMiniEpoch)
-	//assert.NoError(parseResponse([]byte(`{"id":"1","jsonrpc":"2.0","result":"1934"}`), resp))
-	//assert.IsType(&NumResponse{}, resp)
-	//assert.Equal(resp.(*NumResponse).Int64(), int64(1934))
-	//
-	//resps := ResponseTypeOfMethods(GetCurrentMiniEpoch, GetCurrentDSEpoch)
-	//assert.NoError(parseBatchResponse(
-	//	[]byte(`[{"id":"1","jsonrpc":"2.0","result":"1934"},{"id":"1","jsonrpc":"2.0","result":"1935"}]`),
-	//	resps,
-	//))
-	//assert.Equal(resps[0].(*NumResponse).Int64(), int64(1934))
-	//assert.Equal(resps[1].(*NumResponse).Int64(), int64(1935))
-	//
-	//addr, err := testServer([]byte(`{"id":"1","jsonrpc":"2.0","result":"1934"}`))
-	//assert.NoError(err)
-	//cli := New(addr.String(), 10*time.Second)
-	//resp, err = cli.Call(NewRequest(GetCurrentDSEpoch))
-	//assert.NoError(err)
-	//t.Log(nil == error(nil), error(nil) == resp.Error(), reflect.TypeOf(resp.Error()))
-	//assert.NoError(resp.Error())
-	//assert.IsType(&NumResponse{}, resp)
-	//assert.Equal(resp.(*NumResponse).Int64(), int64(1934))
-	//
-	//addr, err = testServer([]byte(`[{"id":"1","jsonrpc":"2.0","result":"1934"},{"id":"1","jsonrpc":"2.0","result":"1935"}]`))
-	//assert.NoError(err)
-	//cli = New(addr.String(), 10*time.Second)
-	//resps, err = cli.CallBatch(NewRequest(GetCurrentDSEpoch), NewRequest(GetCurrentMiniEpoch))
-	//assert.NoError(err)
-	//t.Log(nil == error(nil), error(nil) == resp.Error(), reflect.TypeOf(resp.Error()))
-	//assert.NoError(resp.Error())
-	//assert.IsType(&NumResponse{}, resps[0])
-	//assert.Equal(resps[0].(*NumResponse).Int64(), int64(1934))
-	//assert.Equal(resps[1].(*NumResponse).Int64(), int64(1935))
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult(string(GetCurrentMiniEpoch), "1934")
+	server.SetResult(string(GetCurrentDSEpoch), "20")
+	server.SetResult(string(GetNodeType), "Shard Node of shard 3")
+	server.SetError(string(GetPrevDifficulty), jsonrpc.RPCError{Code: -32600, Message: "Not to be queried on lookup"})
+
+	cli := New(server.Addr(), time.Second, jsonrpc.DefaultTCPOptions())
+	defer cli.Close()
+	epoch, err := cli.GetCurrentMiniEpoch()
+	assert.NoError(err)
+	assert.Equal(int64(1934), epoch)
+
+	nodeType, err := cli.GetNodeType()
+	assert.NoError(err)
+	assert.Equal(ShardNode, nodeType.Type)
+	assert.Equal(3, nodeType.ShardId)
+
+	_, err = cli.GetPrevDifficulty()
+	assert.True(errors.Is(err, jsonrpc.InvalidRequest))
+
+	dsEpochReq := NewGetCurrentDSEpochReq()
+	epochReq := NewGetCurrentMiniEpochReq()
+	batch, err := cli.CallBatch(dsEpochReq, epochReq)
+	assert.NoError(err)
+	resp, err := batch.Get(dsEpochReq)
+	assert.NoError(err)
+	dsEpoch, err := resp.GetInt64()
+	assert.NoError(err)
+	assert.Equal(int64(20), dsEpoch)
+
+	server.SetLatency(100 * time.Millisecond)
+	cli = New(server.Addr(), 20*time.Millisecond, jsonrpc.DefaultTCPOptions())
+	defer cli.Close()
+	_, err = cli.GetCurrentMiniEpoch()
+	assert.Equal(jsonrpc.OutcomeTimeout, jsonrpc.Outcome(err))
 }
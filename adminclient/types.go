@@ -33,6 +33,18 @@ const (
 	ToggleDisableTxns            MethodName = "ToggleDisableTxns"
 )
 
+// QueryMethods are methods without params and side effects, safe to call for probing
+var QueryMethods = []MethodName{
+	GetCurrentMiniEpoch,
+	GetCurrentDSEpoch,
+	GetNodeType,
+	GetDSCommittee,
+	GetNodeState,
+	GetPrevDifficulty,
+	GetPrevDSDifficulty,
+	GetSendSCCallsToDS,
+}
+
 func NewReq(method MethodName, params interface{}) *jsonrpc.Request {
 	return jsonrpc.NewRequest(string(method), params)
 }
@@ -141,6 +153,10 @@ func (n NodeType) String() string {
 	return "Unknown NodeType"
 }
 
+func (n NodeType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
 func (n *NodeType) UnmarshalJSON(data []byte) error {
 	var s string
 	err := json.Unmarshal(data, &s)
@@ -176,6 +192,31 @@ func (n *NodeType) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// DSCommittee is public keys of DS committee members in order, guards first if in guard mode
+type DSCommittee []string
+
+// Position returns index of pubKey in committee, -1 if not a member. Public keys are compared case-insensitively, with or without 0x prefix
+func (c DSCommittee) Position(pubKey string) int {
+	pubKey = NormalizePubKey(pubKey)
+	if pubKey == "" {
+		return -1
+	}
+	for i, member := range c {
+		if NormalizePubKey(member) == pubKey {
+			return i
+		}
+	}
+	return -1
+}
+
+func NormalizePubKey(pubKey string) string {
+	pubKey = strings.TrimSpace(pubKey)
+	if strings.HasPrefix(pubKey, "0x") || strings.HasPrefix(pubKey, "0X") {
+		pubKey = pubKey[2:]
+	}
+	return strings.ToUpper(pubKey)
+}
+
 type NodeState int
 
 const (
@@ -191,6 +232,19 @@ const (
 	SYNC
 )
 
+var NodeStates = []NodeState{
+	POW_SUBMISSION,
+	DSBLOCK_CONSENSUS_PREP,
+	DSBLOCK_CONSENSUS,
+	MICROBLOCK_SUBMISSION,
+	FINALBLOCK_CONSENSUS_PREP,
+	FINALBLOCK_CONSENSUS,
+	VIEWCHANGE_CONSENSUS_PREP,
+	VIEWCHANGE_CONSENSUS,
+	ERROR,
+	SYNC,
+}
+
 var StringNodeStateMap = map[string]NodeState{
 	"POW_SUBMISSION":            POW_SUBMISSION,
 	"DSBLOCK_CONSENSUS_PREP":    DSBLOCK_CONSENSUS_PREP,
@@ -216,6 +270,10 @@ func (n NodeState) String() string {
 	return NodeStateStringMap[n]
 }
 
+func (n NodeState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
 func (n *NodeState) UnmarshalJSON(data []byte) error {
 	var s string
 	err := json.Unmarshal(data, &s)
@@ -0,0 +1,257 @@
+// Package admincmd implements one-shot subcommands calling the admin api (status server) of a zilliqa node.
+package admincmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/collector"
+	"io"
+	"net"
+	"strings"
+	"text/tabwriter"
+)
+
+// output formats
+const (
+	OutputJSON  = "json"
+	OutputTable = "table"
+)
+
+var ErrAborted = errors.New("aborted")
+
+type operation struct {
+	method adminclient.MethodName
+	use    string
+	short  string
+	// names of positional params
+	params []string
+	// mutating operations are confirmed before calling
+	mutating bool
+	call     func(ctx context.Context, cli *adminclient.Client, params []string) (interface{}, error)
+}
+
+var operations = []operation{
+	{method: adminclient.GetNodeType, use: "get-node-type", short: "Get node type and shard of the node",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetNodeTypeContext(ctx)
+		}},
+	{method: adminclient.GetCurrentMiniEpoch, use: "get-current-mini-epoch", short: "Get current TX block number of the node",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetCurrentMiniEpochContext(ctx)
+		}},
+	{method: adminclient.GetCurrentDSEpoch, use: "get-current-ds-epoch", short: "Get current DS block number of the node",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetCurrentDSEpochContext(ctx)
+		}},
+	{method: adminclient.GetNodeState, use: "get-node-state", short: "Get consensus state of the node",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetNodeStateContext(ctx)
+		}},
+	{method: adminclient.GetDSCommittee, use: "get-ds-committee", short: "Get public keys of DS committee members",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetDSCommitteeContext(ctx)
+		}},
+	{method: adminclient.GetPrevDifficulty, use: "get-prev-difficulty", short: "Get the minimum shard difficulty of the previous block",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetPrevDifficultyContext(ctx)
+		}},
+	{method: adminclient.GetPrevDSDifficulty, use: "get-prev-ds-difficulty", short: "Get the minimum DS difficulty of the previous block",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetPrevDSDifficultyContext(ctx)
+		}},
+	{method: adminclient.GetSendSCCallsToDS, use: "get-send-sc-calls-to-ds", short: "Get whether smart contract calls are sent to DS committee",
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.GetSendSCCallsToDSContext(ctx)
+		}},
+	{method: adminclient.IsTxnInMemPool, use: "is-txn-in-mem-pool", short: "Check whether a transaction is in the mempool of the node",
+		params: []string{"txn"},
+		call: func(ctx context.Context, cli *adminclient.Client, params []string) (interface{}, error) {
+			return cli.IsTxnInMemPoolContext(ctx, params[0])
+		}},
+	{method: adminclient.AddToBlacklistExclusion, use: "add-to-blacklist-exclusion", short: "Exclude an ip from the blacklist of the node",
+		params: []string{"ip"}, mutating: true,
+		call: func(ctx context.Context, cli *adminclient.Client, params []string) (interface{}, error) {
+			return cli.AddToBlacklistExclusionContext(ctx, params[0])
+		}},
+	{method: adminclient.RemoveFromBlacklistExclusion, use: "remove-from-blacklist-exclusion", short: "Remove an ip from the blacklist exclusion of the node",
+		params: []string{"ip"}, mutating: true,
+		call: func(ctx context.Context, cli *adminclient.Client, params []string) (interface{}, error) {
+			return cli.RemoveFromBlacklistExclusionContext(ctx, params[0])
+		}},
+	{method: adminclient.ToggleSendSCCallsToDS, use: "toggle-send-sc-calls-to-ds", short: "Toggle sending smart contract calls to DS committee",
+		mutating: true,
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.ToggleSendSCCallsToDSContext(ctx)
+		}},
+	{method: adminclient.DisablePoW, use: "disable-pow", short: "Disable PoW of the node",
+		mutating: true,
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.DisablePoWContext(ctx)
+		}},
+	{method: adminclient.ToggleDisableTxns, use: "toggle-disable-txns", short: "Toggle accepting transactions by the node",
+		mutating: true,
+		call: func(ctx context.Context, cli *adminclient.Client, _ []string) (interface{}, error) {
+			return cli.ToggleDisableTxnsContext(ctx)
+		}},
+}
+
+// Result is the output of an admin subcommand
+type Result struct {
+	Endpoint string      `json:"endpoint"`
+	Method   string      `json:"method"`
+	Params   []string    `json:"params,omitempty"`
+	DryRun   bool        `json:"dry_run,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+}
+
+type flags struct {
+	output string
+	dryRun bool
+	yes    bool
+}
+
+// NewCommand returns the admin command with a subcommand for every admin api method,
+// options should be bound by BindConnectionFlags to persistent flags of the root command.
+func NewCommand(options *collector.Options) *cobra.Command {
+	f := &flags{}
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Call admin api (status server) of the zilliqa node",
+	}
+	cmd.PersistentFlags().StringVarP(&f.output, "output", "o", OutputTable, "output format, json or table")
+	cmd.PersistentFlags().BoolVar(&f.dryRun, "dry-run", false, "print the request without calling admin api")
+	cmd.PersistentFlags().BoolVarP(&f.yes, "yes", "y", false, "do not ask for confirmation of mutating calls")
+	for _, op := range operations {
+		cmd.AddCommand(newOperationCommand(options, f, op))
+	}
+	return cmd
+}
+
+func newOperationCommand(options *collector.Options, f *flags, op operation) *cobra.Command {
+	use := op.use
+	for _, param := range op.params {
+		use += " <" + param + ">"
+	}
+	short := op.short
+	if op.mutating {
+		short += ", asks for confirmation unless --yes"
+	}
+	return &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Aliases: []string{string(op.method)},
+		Args:    cobra.ExactArgs(len(op.params)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, options, f, op, args)
+		},
+	}
+}
+
+func run(cmd *cobra.Command, options *collector.Options, f *flags, op operation, params []string) error {
+	if f.output != OutputJSON && f.output != OutputTable {
+		return errors.Errorf("unknown output format %q, should be json or table", f.output)
+	}
+	for i, name := range op.params {
+		if name == "ip" && net.ParseIP(params[i]) == nil {
+			return errors.Errorf("%q is not an ip address", params[i])
+		}
+	}
+	result := Result{
+		Endpoint: options.AdminEndpoint(),
+		Method:   string(op.method),
+		Params:   params,
+	}
+	if result.Endpoint == "" {
+		return errors.New("admin endpoint not set, see --admin")
+	}
+	if f.dryRun {
+		result.DryRun = true
+		return write(cmd.OutOrStdout(), f.output, result)
+	}
+	if op.mutating && !f.yes {
+		ok, err := confirm(cmd.InOrStdin(), cmd.ErrOrStderr(), result)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrAborted
+		}
+	}
+
+	cli := options.GetAdminOperationClient()
+	if cli == nil {
+		return errors.New("fail to create admin client")
+	}
+	defer cli.Close()
+	ctx := cmd.Context()
+	if timeout := options.RPCTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	value, err := op.call(ctx, cli, params)
+	if err != nil {
+		return errors.Wrapf(err, "fail to call %s on %s", op.method, result.Endpoint)
+	}
+	result.Result = value
+	return write(cmd.OutOrStdout(), f.output, result)
+}
+
+// confirm asks on out and reads the answer from in
+func confirm(in io.Reader, out io.Writer, result Result) (bool, error) {
+	call := result.Method
+	if len(result.Params) > 0 {
+		call += " " + strings.Join(result.Params, " ")
+	}
+	_, _ = fmt.Fprintf(out, "Call %s on %s? [y/N]: ", call, result.Endpoint)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Wrap(err, "fail to read confirmation")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func write(w io.Writer, output string, result Result) error {
+	if output == OutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range tableRows(result) {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+func tableRows(result Result) [][2]string {
+	rows := [][2]string{
+		{"ENDPOINT", result.Endpoint},
+		{"METHOD", result.Method},
+	}
+	if len(result.Params) > 0 {
+		rows = append(rows, [2]string{"PARAMS", strings.Join(result.Params, " ")})
+	}
+	if result.DryRun {
+		rows = append(rows, [2]string{"DRY RUN", "true"})
+	}
+	switch value := result.Result.(type) {
+	case nil:
+	case adminclient.DSCommittee:
+		for i, member := range value {
+			rows = append(rows, [2]string{fmt.Sprintf("RESULT[%d]", i), member})
+		}
+	default:
+		rows = append(rows, [2]string{"RESULT", fmt.Sprint(value)})
+	}
+	return rows
+}
@@ -0,0 +1,92 @@
+package admincmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/collector"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strings"
+	"testing"
+)
+
+func execute(stdin string, args ...string) (string, error) {
+	options := &collector.Options{}
+	root := &cobra.Command{Use: "zilliqa-exporter", SilenceErrors: true, SilenceUsage: true}
+	options.BindConnectionFlags(root.PersistentFlags())
+	options.BindServerFlags(root.Flags())
+	root.AddCommand(NewCommand(options))
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"admin"}, args...))
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestAdminCommand(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("GetNodeType", "Shard Node of shard 2")
+	server.SetResult("GetNodeState", "POW_SUBMISSION")
+	server.SetResult("GetDSCommittee", []string{"0x02AAAA", "0x02BBBB"})
+
+	out, err := execute("", "get-node-type", "--admin", server.Addr(), "-o", "json")
+	assert.NoError(err)
+	var result map[string]interface{}
+	assert.NoError(json.Unmarshal([]byte(out), &result))
+	assert.Equal("GetNodeType", result["method"])
+	assert.Equal("Shard Node of shard 2", result["result"])
+
+	out, err = execute("", "GetNodeState", "--admin", server.Addr())
+	assert.NoError(err)
+	assert.Contains(out, "RESULT    POW_SUBMISSION")
+
+	out, err = execute("", "get-ds-committee", "--admin", server.Addr())
+	assert.NoError(err)
+	assert.Contains(out, "RESULT[1]  0x02BBBB")
+
+	_, err = execute("", "get-node-type", "--admin", server.Addr(), "-o", "yaml")
+	assert.Error(err)
+	_, err = execute("", "get-current-mini-epoch", "--admin", server.Addr())
+	assert.Error(err)
+
+	// only connection flags are shared with the exporter
+	_, err = execute("", "get-node-type", "--admin", server.Addr(), "--rpc-timeout", "1s", "--admin-max-response-size", "1024")
+	assert.NoError(err)
+	_, err = execute("", "get-node-type", "--admin", server.Addr(), "--poll-interval", "1s")
+	assert.Error(err)
+}
+
+func TestAdminCommandMutating(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("AddToBlacklistExclusion", true)
+	server.SetResult("DisablePoW", true)
+
+	_, err := execute("n\n", "add-to-blacklist-exclusion", "10.0.0.1", "--admin", server.Addr())
+	assert.True(errors.Is(err, ErrAborted))
+	assert.Equal(0, server.Calls("AddToBlacklistExclusion"))
+
+	out, err := execute("", "add-to-blacklist-exclusion", "10.0.0.1", "--admin", server.Addr(), "--dry-run", "-o", "json")
+	assert.NoError(err)
+	assert.Contains(out, `"dry_run": true`)
+	assert.Equal(0, server.Calls("AddToBlacklistExclusion"))
+
+	_, err = execute("y\n", "add-to-blacklist-exclusion", "not-an-ip", "--admin", server.Addr())
+	assert.Error(err)
+
+	out, err = execute("y\n", "add-to-blacklist-exclusion", "10.0.0.1", "--admin", server.Addr())
+	assert.NoError(err)
+	assert.Contains(out, "RESULT    true")
+	assert.Equal(1, server.Calls("AddToBlacklistExclusion"))
+
+	_, err = execute("", "disable-pow", "--admin", server.Addr(), "--yes")
+	assert.NoError(err)
+	assert.Equal(1, server.Calls("DisablePoW"))
+}
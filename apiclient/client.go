@@ -0,0 +1,358 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/pkg/errors"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"time"
+)
+
+// Client of zilliqa lookup JsonRPC API
+type Client struct {
+	cli     jsonrpc.Client
+	http    *jsonrpc.HTTPClient
+	timeout time.Duration
+
+	retry    jsonrpc.RetryPolicy
+	breaker  *jsonrpc.CircuitBreaker
+	observer jsonrpc.Observer
+}
+
+func New(endpoint string, timeout time.Duration, opts jsonrpc.HTTPOptions) *Client {
+	cli := jsonrpc.NewHTTPClient(endpoint, opts)
+	return &Client{
+		cli:     cli,
+		http:    cli,
+		timeout: timeout,
+	}
+}
+
+// SetResilience retries failed calls by policy and skips calls while breaker is open
+func (c *Client) SetResilience(policy jsonrpc.RetryPolicy, breaker *jsonrpc.CircuitBreaker) {
+	c.retry = policy
+	c.breaker = breaker
+	c.build()
+}
+
+// Breaker returns the circuit breaker of the client, nil if not set
+func (c *Client) Breaker() *jsonrpc.CircuitBreaker {
+	return c.breaker
+}
+
+// SetObserver reports every call, including each retry, to observer
+func (c *Client) SetObserver(observer jsonrpc.Observer) {
+	c.observer = observer
+	c.build()
+}
+
+func (c *Client) build() {
+	var cli jsonrpc.Client = c.http
+	if c.observer != nil {
+		cli = jsonrpc.NewObservedClient(cli, c.observer)
+	}
+	if c.breaker != nil || c.retry.MaxAttempts > 1 {
+		cli = jsonrpc.NewResilientClient(cli, c.retry, c.breaker)
+	}
+	c.cli = cli
+}
+
+// Address returns the endpoint in use
+func (c *Client) Address() string {
+	return c.cli.Address()
+}
+
+func (c Client) defaultCtx() (context.Context, context.CancelFunc) {
+	if c.timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *Client) getRespContext(ctx context.Context, request *jsonrpc.Request) (*jsonrpc.Response, error) {
+	resp, err := c.cli.CallContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	err = resp.Err()
+	if err != nil {
+		return nil, err
+	}
+	return resp, err
+}
+
+func (c *Client) getResp(request *jsonrpc.Request) (*jsonrpc.Response, error) {
+	ctx, cancel := c.defaultCtx()
+	defer cancel()
+	resp, err := c.getRespContext(ctx, request)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil, errors.Wrap(err, "timeout: "+c.timeout.String())
+	}
+	return resp, err
+}
+
+// CallContext calls any method, the error of the response is returned as error
+func (c *Client) CallContext(ctx context.Context, request *jsonrpc.Request) (*jsonrpc.Response, error) {
+	return c.getRespContext(ctx, request)
+}
+
+func (c *Client) CallBatch(req ...*jsonrpc.Request) (*jsonrpc.BatchResponse, error) {
+	ctx, cancel := c.defaultCtx()
+	defer cancel()
+	return c.CallBatchContext(ctx, req...)
+}
+
+func (c *Client) CallBatchContext(ctx context.Context, req ...*jsonrpc.Request) (*jsonrpc.BatchResponse, error) {
+	return c.cli.CallBatchContext(ctx, req...)
+}
+
+func (c *Client) GetBlockchainInfo() (*core.BlockchainInfo, error) {
+	resp, err := c.getResp(NewGetBlockchainInfoReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlockchainInfo(resp)
+}
+
+func (c *Client) GetBlockchainInfoContext(ctx context.Context) (*core.BlockchainInfo, error) {
+	resp, err := c.getRespContext(ctx, NewGetBlockchainInfoReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlockchainInfo(resp)
+}
+
+func (c *Client) GetNetworkId() (string, error) {
+	resp, err := c.getResp(NewGetNetworkIdReq())
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString()
+}
+
+func (c *Client) GetNetworkIdContext(ctx context.Context) (string, error) {
+	resp, err := c.getRespContext(ctx, NewGetNetworkIdReq())
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString()
+}
+
+func (c *Client) GetPrevDifficulty() (int64, error) {
+	resp, err := c.getResp(NewGetPrevDifficultyReq())
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetInt64()
+}
+
+func (c *Client) GetPrevDifficultyContext(ctx context.Context) (int64, error) {
+	resp, err := c.getRespContext(ctx, NewGetPrevDifficultyReq())
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetInt64()
+}
+
+func (c *Client) GetPrevDSDifficulty() (int64, error) {
+	resp, err := c.getResp(NewGetPrevDSDifficultyReq())
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetInt64()
+}
+
+func (c *Client) GetPrevDSDifficultyContext(ctx context.Context) (int64, error) {
+	resp, err := c.getRespContext(ctx, NewGetPrevDSDifficultyReq())
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetInt64()
+}
+
+func (c *Client) GetLatestTxBlock() (*core.TxBlock, error) {
+	resp, err := c.getResp(NewGetLatestTxBlockReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseTxBlock(resp)
+}
+
+func (c *Client) GetLatestTxBlockContext(ctx context.Context) (*core.TxBlock, error) {
+	resp, err := c.getRespContext(ctx, NewGetLatestTxBlockReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseTxBlock(resp)
+}
+
+func (c *Client) GetLatestDsBlock() (*core.DSBlock, error) {
+	resp, err := c.getResp(NewGetLatestDsBlockReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseDSBlock(resp)
+}
+
+func (c *Client) GetLatestDsBlockContext(ctx context.Context) (*core.DSBlock, error) {
+	resp, err := c.getRespContext(ctx, NewGetLatestDsBlockReq())
+	if err != nil {
+		return nil, err
+	}
+	return ParseDSBlock(resp)
+}
+
+func (c *Client) GetTxBlock(blockNum uint64) (*core.TxBlock, error) {
+	resp, err := c.getResp(NewGetTxBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTxBlock(resp)
+}
+
+func (c *Client) GetTxBlockContext(ctx context.Context, blockNum uint64) (*core.TxBlock, error) {
+	resp, err := c.getRespContext(ctx, NewGetTxBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTxBlock(resp)
+}
+
+// GetTransactionsForTxBlock returns hashes of transactions of the block, by micro block
+func (c *Client) GetTransactionsForTxBlock(blockNum uint64) ([][]string, error) {
+	resp, err := c.getResp(NewGetTransactionsForTxBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTransactionsForTxBlock(resp)
+}
+
+func (c *Client) GetTransactionsForTxBlockContext(ctx context.Context, blockNum uint64) ([][]string, error) {
+	resp, err := c.getRespContext(ctx, NewGetTransactionsForTxBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseTransactionsForTxBlock(resp)
+}
+
+func (c *Client) GetDsBlock(blockNum uint64) (*core.DSBlock, error) {
+	resp, err := c.getResp(NewGetDsBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseDSBlock(resp)
+}
+
+func (c *Client) GetDsBlockContext(ctx context.Context, blockNum uint64) (*core.DSBlock, error) {
+	resp, err := c.getRespContext(ctx, NewGetDsBlockReq(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseDSBlock(resp)
+}
+
+// GetMinerInfo returns DS committee and shards formed in the DS epoch
+func (c *Client) GetMinerInfo(dsBlockNum uint64) (*core.MinerInfo, error) {
+	resp, err := c.getResp(NewGetMinerInfoReq(dsBlockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseMinerInfo(resp)
+}
+
+func (c *Client) GetMinerInfoContext(ctx context.Context, dsBlockNum uint64) (*core.MinerInfo, error) {
+	resp, err := c.getRespContext(ctx, NewGetMinerInfoReq(dsBlockNum))
+	if err != nil {
+		return nil, err
+	}
+	return ParseMinerInfo(resp)
+}
+
+// GetSmartContractState returns the raw state json of the contract
+func (c *Client) GetSmartContractState(address string) (json.RawMessage, error) {
+	resp, err := c.getResp(NewGetSmartContractStateReq(address))
+	if err != nil {
+		return nil, err
+	}
+	return resp.RawResult(), nil
+}
+
+func (c *Client) GetSmartContractStateContext(ctx context.Context, address string) (json.RawMessage, error) {
+	resp, err := c.getRespContext(ctx, NewGetSmartContractStateReq(address))
+	if err != nil {
+		return nil, err
+	}
+	return resp.RawResult(), nil
+}
+
+// GetBalance returns balance in Qa and nonce of the account
+func (c *Client) GetBalance(address string) (*core.BalanceAndNonce, error) {
+	resp, err := c.getResp(NewGetBalanceReq(address))
+	if err != nil {
+		return nil, err
+	}
+	return ParseBalance(resp)
+}
+
+func (c *Client) GetBalanceContext(ctx context.Context, address string) (*core.BalanceAndNonce, error) {
+	resp, err := c.getRespContext(ctx, NewGetBalanceReq(address))
+	if err != nil {
+		return nil, err
+	}
+	return ParseBalance(resp)
+}
+
+func ParseBlockchainInfo(resp *jsonrpc.Response) (*core.BlockchainInfo, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	info := &core.BlockchainInfo{}
+	err := resp.GetObject(info)
+	return info, err
+}
+
+func ParseTxBlock(resp *jsonrpc.Response) (*core.TxBlock, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	block := &core.TxBlock{}
+	err := resp.GetObject(block)
+	return block, err
+}
+
+func ParseDSBlock(resp *jsonrpc.Response) (*core.DSBlock, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	block := &core.DSBlock{}
+	err := resp.GetObject(block)
+	return block, err
+}
+
+func ParseTransactionsForTxBlock(resp *jsonrpc.Response) ([][]string, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	var txns [][]string
+	err := resp.GetObject(&txns)
+	return txns, err
+}
+
+func ParseMinerInfo(resp *jsonrpc.Response) (*core.MinerInfo, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	info := &core.MinerInfo{}
+	err := resp.GetObject(info)
+	return info, err
+}
+
+func ParseBalance(resp *jsonrpc.Response) (*core.BalanceAndNonce, error) {
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	balance := &core.BalanceAndNonce{}
+	err := resp.GetObject(balance)
+	return balance, err
+}
@@ -0,0 +1,77 @@
+package apiclient
+
+import (
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"strconv"
+)
+
+type MethodName string
+
+// method names
+const (
+	GetBlockchainInfo     MethodName = "GetBlockchainInfo"
+	GetNetworkId          MethodName = "GetNetworkId"
+	GetPrevDifficulty     MethodName = "GetPrevDifficulty"
+	GetPrevDSDifficulty   MethodName = "GetPrevDSDifficulty"
+	GetLatestTxBlock      MethodName = "GetLatestTxBlock"
+	GetLatestDsBlock      MethodName = "GetLatestDsBlock"
+	GetSmartContractState MethodName = "GetSmartContractState"
+
+	GetTxBlock                MethodName = "GetTxBlock"
+	GetTransactionsForTxBlock MethodName = "GetTransactionsForTxBlock"
+	GetDsBlock                MethodName = "GetDsBlock"
+	GetMinerInfo              MethodName = "GetMinerInfo"
+	GetBalance                MethodName = "GetBalance"
+)
+
+func NewReq(method MethodName, params interface{}) *jsonrpc.Request {
+	return jsonrpc.NewRequest(string(method), params)
+}
+
+func NewGetBlockchainInfoReq() *jsonrpc.Request {
+	return NewReq(GetBlockchainInfo, nil)
+}
+
+func NewGetNetworkIdReq() *jsonrpc.Request {
+	return NewReq(GetNetworkId, nil)
+}
+
+func NewGetPrevDifficultyReq() *jsonrpc.Request {
+	return NewReq(GetPrevDifficulty, nil)
+}
+
+func NewGetPrevDSDifficultyReq() *jsonrpc.Request {
+	return NewReq(GetPrevDSDifficulty, nil)
+}
+
+func NewGetLatestTxBlockReq() *jsonrpc.Request {
+	return NewReq(GetLatestTxBlock, nil)
+}
+
+func NewGetLatestDsBlockReq() *jsonrpc.Request {
+	return NewReq(GetLatestDsBlock, nil)
+}
+
+func NewGetSmartContractStateReq(address string) *jsonrpc.Request {
+	return NewReq(GetSmartContractState, []string{address})
+}
+
+func NewGetTxBlockReq(blockNum uint64) *jsonrpc.Request {
+	return NewReq(GetTxBlock, []string{strconv.FormatUint(blockNum, 10)})
+}
+
+func NewGetTransactionsForTxBlockReq(blockNum uint64) *jsonrpc.Request {
+	return NewReq(GetTransactionsForTxBlock, []string{strconv.FormatUint(blockNum, 10)})
+}
+
+func NewGetDsBlockReq(blockNum uint64) *jsonrpc.Request {
+	return NewReq(GetDsBlock, []string{strconv.FormatUint(blockNum, 10)})
+}
+
+func NewGetMinerInfoReq(dsBlockNum uint64) *jsonrpc.Request {
+	return NewReq(GetMinerInfo, []string{strconv.FormatUint(dsBlockNum, 10)})
+}
+
+func NewGetBalanceReq(address string) *jsonrpc.Request {
+	return NewReq(GetBalance, []string{address})
+}
@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// QaPerZil is the number of Qa in one ZIL
+const QaPerZil = 1e12
+
+// errAccountNotCreated is returned by GetBalance for an address never received any fund
+const errAccountNotCreated = "Account is not created"
+
+type accountState struct {
+	balance    float64 // in Qa
+	nonce      int64
+	increments uint64 // nonce increments seen by the watcher
+}
+
+// AccountWatcher polls balances and nonces of accounts in config with one batched GetBalance request every interval
+type AccountWatcher struct {
+	options   *Options
+	constants *Constants
+	client    *apiclient.Client
+	accounts  []AccountWatch
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	mu     sync.Mutex
+	states map[string]*accountState // by account name
+
+	balanceZil      *prometheus.Desc
+	balanceQa       *prometheus.Desc
+	nonce           *prometheus.Desc
+	nonceIncrements *prometheus.Desc
+}
+
+func NewAccountWatcher(constants *Constants, accounts []AccountWatch) *AccountWatcher {
+	commonLabels := constants.CommonLabels()
+	accountLabels := append([]string{"account", "address"}, commonLabels...)
+	return &AccountWatcher{
+		options:   constants.options,
+		constants: constants,
+		client:    constants.options.GetAPIClient(),
+		accounts:  accounts,
+		interval:  constants.options.accountInterval,
+		stop:      make(chan struct{}),
+		states:    make(map[string]*accountState),
+		balanceZil: prometheus.NewDesc(
+			"account_balance_zil", "Balance of the account in ZIL",
+			accountLabels, nil,
+		),
+		balanceQa: prometheus.NewDesc(
+			"account_balance_qa", "Balance of the account in Qa",
+			accountLabels, nil,
+		),
+		nonce: prometheus.NewDesc(
+			"account_nonce", "Nonce of the account",
+			accountLabels, nil,
+		),
+		nonceIncrements: prometheus.NewDesc(
+			"account_nonce_increments_total", "Nonce increments of the account seen by the watcher, as transactions sent by it",
+			accountLabels, nil,
+		),
+	}
+}
+
+// Start polls accounts every interval until Stop is called, it blocks
+func (w *AccountWatcher) Start() {
+	if len(w.accounts) == 0 {
+		return
+	}
+	if w.interval <= 0 || w.client == nil {
+		log.Info("account watcher disabled")
+		return
+	}
+	log.WithField("interval", w.interval).WithField("accounts", len(w.accounts)).Info("start watching accounts")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.pollOnce()
+		select {
+		case <-w.stop:
+			log.Debug("stop watching accounts")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *AccountWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+func (w *AccountWatcher) pollOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.options.RPCTimeout())
+	defer cancel()
+	requests := make([]*jsonrpc.Request, 0, len(w.accounts))
+	for _, account := range w.accounts {
+		requests = append(requests, apiclient.NewGetBalanceReq(account.Address))
+	}
+	batch, err := w.client.CallBatchContext(ctx, requests...)
+	if err != nil {
+		logRPCError(err, "fail to get balances of accounts")
+		return
+	}
+	for i, account := range w.accounts {
+		balance, err := batchBalance(batch, requests[i])
+		if err != nil {
+			log.WithError(err).WithField("account", account.Name).Error("fail to get balance of account")
+			continue
+		}
+		qa, err := strconv.ParseFloat(balance.Balance, 64)
+		if err != nil {
+			log.WithError(err).WithField("account", account.Name).Error("invalid balance of account")
+			continue
+		}
+		w.observe(account.Name, qa, balance.Nonce)
+	}
+}
+
+// batchBalance gets balance from batch, an account not created yet has zero balance and nonce
+func batchBalance(batch *jsonrpc.BatchResponse, req *jsonrpc.Request) (*core.BalanceAndNonce, error) {
+	resp, err := batch.Get(req)
+	if err != nil {
+		if rpcErr, ok := errors.Cause(err).(*jsonrpc.RPCError); ok && rpcErr.Message == errAccountNotCreated {
+			return &core.BalanceAndNonce{Balance: "0"}, nil
+		}
+		return nil, err
+	}
+	return apiclient.ParseBalance(resp)
+}
+
+func (w *AccountWatcher) observe(name string, balance float64, nonce int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	state, ok := w.states[name]
+	if !ok {
+		w.states[name] = &accountState{balance: balance, nonce: nonce}
+		return
+	}
+	if nonce > state.nonce {
+		state.increments += uint64(nonce - state.nonce)
+	}
+	state.balance, state.nonce = balance, nonce
+}
+
+func (w *AccountWatcher) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.balanceZil
+	ch <- w.balanceQa
+	ch <- w.nonce
+	ch <- w.nonceIncrements
+}
+
+func (w *AccountWatcher) Collect(ch chan<- prometheus.Metric) {
+	labels := w.constants.CommonLabelValues()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, account := range w.accounts {
+		state, ok := w.states[account.Name]
+		if !ok {
+			continue
+		}
+		accountLabels := append([]string{account.Name, account.Address}, labels...)
+		ch <- prometheus.MustNewConstMetric(w.balanceZil, prometheus.GaugeValue, state.balance/QaPerZil, accountLabels...)
+		ch <- prometheus.MustNewConstMetric(w.balanceQa, prometheus.GaugeValue, state.balance, accountLabels...)
+		ch <- prometheus.MustNewConstMetric(w.nonce, prometheus.GaugeValue, float64(state.nonce), accountLabels...)
+		ch <- prometheus.MustNewConstMetric(w.nonceIncrements, prometheus.CounterValue, float64(state.increments), accountLabels...)
+	}
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strings"
+	"testing"
+)
+
+const (
+	testGasAccount    = "4baf5fada8e5db92c3d3242618c5b47133ae003c"
+	testRewardAccount = "448261915a80cde9bde7c7a791685200d3a0bf4e"
+)
+
+func TestAccountWatcher(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	gasNonces := []int64{10, 13}
+	server.SetHandler("GetBalance", func(call int, params json.RawMessage) (interface{}, error) {
+		var addresses []string
+		if err := json.Unmarshal(params, &addresses); err != nil {
+			return nil, err
+		}
+		switch addresses[0] {
+		case testGasAccount:
+			// both accounts are called in every poll, in order
+			n := (call + 1) / 2
+			return core.BalanceAndNonce{Balance: "1500000000000", Nonce: gasNonces[n-1]}, nil
+		default:
+			return nil, jsonrpc.RPCError{Code: -5, Message: errAccountNotCreated}
+		}
+	})
+
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	w := NewAccountWatcher(constants, []AccountWatch{
+		{Name: "gas", Address: testGasAccount},
+		{Name: "reward", Address: testRewardAccount},
+	})
+	w.pollOnce()
+	w.pollOnce()
+	assert.Equal(4, server.Calls("GetBalance"))
+
+	gasLabels := labelText(constants, "account", "gas", "address", testGasAccount)
+	rewardLabels := labelText(constants, "account", "reward", "address", testRewardAccount)
+	expected := fmt.Sprintf(`
+# HELP account_balance_zil Balance of the account in ZIL
+# TYPE account_balance_zil gauge
+account_balance_zil%s 1.5
+account_balance_zil%s 0
+# HELP account_balance_qa Balance of the account in Qa
+# TYPE account_balance_qa gauge
+account_balance_qa%s 1.5e+12
+account_balance_qa%s 0
+# HELP account_nonce Nonce of the account
+# TYPE account_nonce gauge
+account_nonce%s 13
+account_nonce%s 0
+# HELP account_nonce_increments_total Nonce increments of the account seen by the watcher, as transactions sent by it
+# TYPE account_nonce_increments_total counter
+account_nonce_increments_total%s 3
+account_nonce_increments_total%s 0
+`,
+		gasLabels, rewardLabels, gasLabels, rewardLabels, gasLabels, rewardLabels, gasLabels, rewardLabels,
+	)
+	assert.NoError(testutil.CollectAndCompare(w, strings.NewReader(expected)))
+}
+
+func TestAccountWatcherError(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	server.SetError("GetBalance", jsonrpc.InvalidParams)
+
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	w := NewAccountWatcher(constants, []AccountWatch{{Name: "gas", Address: testGasAccount}})
+	w.pollOnce()
+	assert.Equal(0, testutil.CollectAndCount(w))
+}
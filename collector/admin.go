@@ -1,9 +1,12 @@
 package collector
 
 import (
+	"context"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
 	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
 )
 
@@ -11,10 +14,19 @@ import (
 type AdminCollector struct {
 	options   *Options
 	constants *Constants
+	client    *adminclient.Client
+	// for leader of the latest DS block
+	apiClient *apiclient.Client
+	committee dsCommitteeTracker
 
 	// admin api server up
 	adminServerUp *prometheus.Desc
 
+	// connection pool of admin client
+	connPoolConnections *prometheus.Desc
+	connPoolEvents      *prometheus.Desc
+	breakerState        *prometheus.Desc
+
 	// block-chain related
 	// from admin & api
 	epoch   *prometheus.Desc
@@ -31,6 +43,14 @@ type AdminCollector struct {
 	nodeType *prometheus.Desc
 	shardId  *prometheus.Desc
 
+	// GetDSCommittee of admin, not for lookup
+	dsCommitteeSize            *prometheus.Desc
+	dsCommitteePosition        *prometheus.Desc
+	dsCommitteeIsDSBlockLeader *prometheus.Desc
+	dsCommitteeGuards          *prometheus.Desc
+	dsCommitteeChanges         *prometheus.Desc
+	dsCommitteeNodeChanges     *prometheus.Desc
+
 	// https://github.com/Zilliqa/Zilliqa/blob/master/src/libDirectoryService/DirectoryService.h
 	// enum DirState : unsigned char {
 	//	POW_SUBMISSION = 0x00,
@@ -46,17 +66,39 @@ type AdminCollector struct {
 	// }
 	// Not to be queried on lookup
 	nodeState *prometheus.Desc
+
+	// probed query methods, unsupported ones are not called, every method is called without probing if nil
+	capabilities    *adminCapabilities
+	methodSupported *prometheus.Desc
+
+	// methods called besides GetNodeType, all query methods if nil
+	methods map[adminclient.MethodName]bool
 }
 
 func NewAdminCollector(constants *Constants) *AdminCollector {
 	commonLabels := constants.CommonLabels()
 	return &AdminCollector{
-		options:   constants.options,
-		constants: constants,
+		options:      constants.options,
+		constants:    constants,
+		client:       constants.options.GetAdminClient(),
+		apiClient:    constants.options.GetAPIClient(),
+		capabilities: newAdminCapabilities(constants.options.adminMethodProbeInterval),
 		adminServerUp: prometheus.NewDesc(
 			"admin_server_up", "Admin JsonRPC server (status server) up and running",
 			append([]string{"endpoint"}, commonLabels...), nil,
 		),
+		connPoolConnections: prometheus.NewDesc(
+			"admin_conn_pool_connections", "Connections to admin JsonRPC server kept by the exporter",
+			append([]string{"endpoint", "state"}, commonLabels...), nil,
+		),
+		connPoolEvents: prometheus.NewDesc(
+			"admin_conn_pool_events_total", "Connection pool events of admin JsonRPC client",
+			append([]string{"endpoint", "event"}, commonLabels...), nil,
+		),
+		breakerState: prometheus.NewDesc(
+			"admin_circuit_breaker_state", "Circuit breaker state of admin JsonRPC client, 1 for the current state",
+			append([]string{"endpoint", "state"}, commonLabels...), nil,
+		),
 		epoch: prometheus.NewDesc(
 			"epoch", "Current TX block number of the node",
 			commonLabels, nil,
@@ -81,15 +123,47 @@ func NewAdminCollector(constants *Constants) *AdminCollector {
 			"shard_id", "Shard ID of the shard of current node",
 			commonLabels, nil,
 		),
+		dsCommitteeSize: prometheus.NewDesc(
+			"ds_committee_size", "Members of DS committee",
+			commonLabels, nil,
+		),
+		dsCommitteePosition: prometheus.NewDesc(
+			"ds_committee_position", "Position of current node in DS committee, -1 if not a member",
+			commonLabels, nil,
+		),
+		dsCommitteeIsDSBlockLeader: prometheus.NewDesc(
+			"ds_committee_is_ds_block_leader", "Whether current node led the consensus of the latest DS block, the current DS leader may differ after view changes",
+			commonLabels, nil,
+		),
+		dsCommitteeGuards: prometheus.NewDesc(
+			"ds_committee_guards", "DS guards in DS committee",
+			commonLabels, nil,
+		),
+		dsCommitteeChanges: prometheus.NewDesc(
+			"ds_committee_changes_total", "Members joined or left DS committee across DS epochs",
+			append([]string{"change"}, commonLabels...), nil,
+		),
+		dsCommitteeNodeChanges: prometheus.NewDesc(
+			"ds_committee_node_changes_total", "Times current node joined or left DS committee across DS epochs",
+			append([]string{"change"}, commonLabels...), nil,
+		),
 		nodeState: prometheus.NewDesc(
-			"node_state", "Node state",
-			append([]string{"text"}, commonLabels...), nil,
+			"node_state", "Node state, 1 for the current state",
+			append([]string{"state"}, commonLabels...), nil,
+		),
+		methodSupported: prometheus.NewDesc(
+			"admin_method_supported", "Whether the admin API method is supported by the node",
+			append([]string{"method"}, commonLabels...), nil,
 		),
 	}
 }
 
 func (c *AdminCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.adminServerUp
+	ch <- c.connPoolConnections
+	ch <- c.connPoolEvents
+	ch <- c.breakerState
+	ch <- c.methodSupported
 	ch <- c.nodeType
 	ch <- c.epoch
 	ch <- c.dsEpoch
@@ -97,22 +171,37 @@ func (c *AdminCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.dsDifficulty
 	if !IsGeneralLookup(c.constants.NodeType()) {
 		ch <- c.shardId
-		//ch <- c.nodeState
+		ch <- c.dsCommitteeSize
+		ch <- c.dsCommitteePosition
+		ch <- c.dsCommitteeIsDSBlockLeader
+		ch <- c.dsCommitteeGuards
+		ch <- c.dsCommitteeChanges
+		ch <- c.dsCommitteeNodeChanges
+		ch <- c.nodeState
 	}
 }
 
 func (c *AdminCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects from admin API, calls are canceled when ctx is done
+func (c *AdminCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	labels := c.constants.CommonLabelValues()
 	log.Debug("enter admin collector")
-	cli := c.options.GetAdminClient()
+	cli := c.client
 	if cli == nil {
-		log.Error("API endpoint not set")
+		log.Error("admin client not initialized, check admin endpoint and TLS options")
 		return
 	}
+	defer c.collectPoolStats(ch, labels)
+	defer collectBreakerState(ch, c.breakerState, cli.Breaker(), c.options.AdminEndpoint(), labels)
+	ctx, cancel := context.WithTimeout(ctx, c.options.RPCTimeout())
+	defer cancel()
 	log.Debug("GetNodeType from admin API")
-	nodeType, err := cli.GetNodeType()
+	nodeType, err := cli.GetNodeTypeContext(ctx)
 	if err != nil {
-		log.WithError(err).Error("error while getting NodeType from admin API")
+		logRPCError(err, "error while getting NodeType from admin API")
 		ch <- prometheus.MustNewConstMetric(c.adminServerUp, prometheus.GaugeValue, float64(0),
 			append([]string{c.options.AdminEndpoint()}, labels...)...)
 		return
@@ -126,57 +215,207 @@ func (c *AdminCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.shardId, prometheus.GaugeValue, float64(nodeType.ShardId), labels...)
 	}
 
-	reqs := []*jsonrpc.Request{
-		adminclient.NewGetCurrentMiniEpochReq(),
-		adminclient.NewGetCurrentDSEpochReq(),
-		adminclient.NewGetPrevDifficultyReq(),
-		adminclient.NewGetPrevDSDifficultyReq(),
-		//adminclient.NewGetNodeStateReq(),
+	var supported map[adminclient.MethodName]bool
+	if c.capabilities != nil {
+		supported, err = c.capabilities.supported(ctx, cli)
+		if err != nil {
+			logRPCError(err, "error while probing methods of admin API")
+		}
+	}
+	for method, ok := range supported {
+		var value float64
+		if ok {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.methodSupported, prometheus.GaugeValue, value,
+			append([]string{string(method)}, labels...)...)
 	}
 
-	log.Debug("batch GetCurrentMiniEpoch, GetCurrentDSEpoch, GetPrevDifficulty, GetPrevDSDifficulty, GetNodeState from admin API")
-	resps, err := cli.CallBatch(reqs...)
-	if err != nil {
-		log.WithError(err).Error("error while getting non-lookup infos from admin API")
-	} else if len(resps) != len(reqs) {
-		log.Errorf("unknown error while getting non-lookup infos from admin API, responses less than %d", len(reqs))
+	var requests []*jsonrpc.Request
+	newReq := func(method adminclient.MethodName) *jsonrpc.Request {
+		// all methods are called until probed
+		if supported != nil && !supported[method] {
+			log.WithField("method", method).Debug("skip method unsupported by admin API")
+			return nil
+		}
+		if c.methods != nil && !c.methods[method] {
+			return nil
+		}
+		req := adminclient.NewReq(method, nil)
+		requests = append(requests, req)
+		return req
+	}
+	epochReq := newReq(adminclient.GetCurrentMiniEpoch)
+	dsEpochReq := newReq(adminclient.GetCurrentDSEpoch)
+	diffReq := newReq(adminclient.GetPrevDifficulty)
+	dsDiffReq := newReq(adminclient.GetPrevDSDifficulty)
+	var committeeReq, stateReq *jsonrpc.Request
+	if !IsGeneralLookup(c.constants.NodeType()) {
+		committeeReq = newReq(adminclient.GetDSCommittee)
+		stateReq = newReq(adminclient.GetNodeState)
+	}
+	if len(requests) == 0 {
+		return
 	}
 
-	epoch, err := resps[0].GetFloat64()
+	log.Debug("batch GetCurrentMiniEpoch, GetCurrentDSEpoch, GetPrevDifficulty, GetPrevDSDifficulty, GetDSCommittee, GetNodeState from admin API")
+	batch, err := cli.CallBatchContext(ctx, requests...)
 	if err != nil {
-		log.WithError(err).Error("error while getting miniEpoch from admin API")
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.epoch, prometheus.GaugeValue, epoch, labels...)
+		logRPCError(err, "error while getting non-lookup infos from admin API")
+		return
+	}
+	if unmatched := batch.Unmatched(); len(unmatched) > 0 {
+		log.WithField("count", len(unmatched)).Warn("got unexpected responses from admin API")
+	}
+	if c.capabilities != nil {
+		for _, req := range requests {
+			if errors.Is(batch.Err(req), jsonrpc.MethodNotFound) {
+				c.capabilities.invalidate()
+				break
+			}
+		}
 	}
 
-	dsEpoch, err := resps[1].GetFloat64()
-	if err != nil {
-		log.WithError(err).Error("error while getting dsEpoch from admin API")
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.dsEpoch, prometheus.GaugeValue, dsEpoch, labels...)
+	if epochReq != nil {
+		epoch, err := batchFloat64(batch, epochReq)
+		if err != nil {
+			log.WithError(err).Error("error while getting miniEpoch from admin API")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.epoch, prometheus.GaugeValue, epoch, labels...)
+		}
 	}
 
-	diff, err := resps[2].GetFloat64()
-	if err != nil {
-		log.WithError(err).Error("error while getting prevDifficulty from admin API")
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.difficulty, prometheus.GaugeValue, diff, labels...)
+	var dsEpoch float64
+	var dsEpochOK bool
+	if dsEpochReq != nil {
+		var err error
+		dsEpoch, err = batchFloat64(batch, dsEpochReq)
+		if err != nil {
+			log.WithError(err).Error("error while getting dsEpoch from admin API")
+		} else {
+			dsEpochOK = true
+			ch <- prometheus.MustNewConstMetric(c.dsEpoch, prometheus.GaugeValue, dsEpoch, labels...)
+		}
 	}
 
-	dsDiff, err := resps[3].GetFloat64()
-	if err != nil {
-		log.WithError(err).Error("error while getting prevDSDifficulty from admin API")
-	} else {
-		ch <- prometheus.MustNewConstMetric(c.dsDifficulty, prometheus.GaugeValue, dsDiff, labels...)
-	}
-
-	// TODO: node state
-	//var state adminclient.NodeState
-	//err = resps[4].GetObject(&state)
-	//if err != nil {
-	//	log.WithError(err).Error("error while getting nodeState from admin API")
-	//} else {
-	//	ch <- prometheus.MustNewConstMetric(c.nodeState, prometheus.GaugeValue, float64(state), state.String(), labels...)
-	//}
+	if diffReq != nil {
+		diff, err := batchFloat64(batch, diffReq)
+		if err != nil {
+			log.WithError(err).Error("error while getting prevDifficulty from admin API")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.difficulty, prometheus.GaugeValue, diff, labels...)
+		}
+	}
+
+	if dsDiffReq != nil {
+		dsDiff, err := batchFloat64(batch, dsDiffReq)
+		if err != nil {
+			log.WithError(err).Error("error while getting prevDSDifficulty from admin API")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.dsDifficulty, prometheus.GaugeValue, dsDiff, labels...)
+		}
+	}
+
+	if committeeReq != nil {
+		var committee adminclient.DSCommittee
+		err := batchObject(batch, committeeReq, &committee)
+		if err != nil {
+			log.WithError(err).Error("error while getting DS committee from admin API")
+		} else {
+			if dsEpochOK {
+				c.committee.update(int64(dsEpoch), committee, c.constants.PubKey())
+			}
+			c.collectDSCommittee(ctx, ch, committee, labels)
+		}
+	}
+
+	if stateReq != nil {
+		var state adminclient.NodeState
+		err := batchObject(batch, stateReq, &state)
+		if err != nil {
+			log.WithError(err).Error("error while getting nodeState from admin API")
+		} else {
+			for _, s := range adminclient.NodeStates {
+				var value float64
+				if s == state {
+					value = 1
+				}
+				ch <- prometheus.MustNewConstMetric(c.nodeState, prometheus.GaugeValue, value,
+					append([]string{s.String()}, labels...)...)
+			}
+		}
+	}
 	log.Debug("exit admin collector")
 }
+
+func (c *AdminCollector) collectDSCommittee(ctx context.Context, ch chan<- prometheus.Metric, committee adminclient.DSCommittee, labels []string) {
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeSize, prometheus.GaugeValue, float64(len(committee)), labels...)
+
+	if path := c.constants.ZilliqaConstantsPath(); path != "" {
+		guards, err := ReadDSGuards(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Debug("fail to read DS guards")
+		} else {
+			var count int
+			for _, guard := range guards {
+				if committee.Position(guard) >= 0 {
+					count++
+				}
+			}
+			ch <- prometheus.MustNewConstMetric(c.dsCommitteeGuards, prometheus.GaugeValue, float64(count), labels...)
+		}
+	}
+
+	joined, left, nodeJoined, nodeLeft := c.committee.changes()
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeChanges, prometheus.CounterValue, float64(joined), append([]string{"joined"}, labels...)...)
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeChanges, prometheus.CounterValue, float64(left), append([]string{"left"}, labels...)...)
+
+	pubKey := c.constants.PubKey()
+	if pubKey == "" {
+		log.Debug("public key of node unknown, skip DS committee position")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeNodeChanges, prometheus.CounterValue, float64(nodeJoined), append([]string{"joined"}, labels...)...)
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeNodeChanges, prometheus.CounterValue, float64(nodeLeft), append([]string{"left"}, labels...)...)
+	position := committee.Position(pubKey)
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteePosition, prometheus.GaugeValue, float64(position), labels...)
+
+	var isLeader float64
+	if position >= 0 {
+		if c.apiClient == nil {
+			return
+		}
+		block, err := c.apiClient.GetLatestDsBlockContext(ctx)
+		if err != nil {
+			logRPCError(err, "error while getting leader of the latest DS block")
+			return
+		}
+		if adminclient.NormalizePubKey(block.Header.LeaderPubKey) == adminclient.NormalizePubKey(pubKey) {
+			isLeader = 1
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.dsCommitteeIsDSBlockLeader, prometheus.GaugeValue, isLeader, labels...)
+}
+
+func (c *AdminCollector) collectPoolStats(ch chan<- prometheus.Metric, labels []string) {
+	stats := c.client.PoolStats()
+	endpoint := c.options.AdminEndpoint()
+	connections := map[string]int{
+		"idle":   stats.IdleConns,
+		"in_use": stats.OpenConns - stats.IdleConns,
+	}
+	for state, count := range connections {
+		ch <- prometheus.MustNewConstMetric(c.connPoolConnections, prometheus.GaugeValue, float64(count),
+			append([]string{endpoint, state}, labels...)...)
+	}
+	events := map[string]uint64{
+		"hit":        stats.Hits,
+		"miss":       stats.Misses,
+		"dial_error": stats.DialErrors,
+		"eviction":   stats.Evictions,
+	}
+	for event, count := range events {
+		ch <- prometheus.MustNewConstMetric(c.connPoolEvents, prometheus.CounterValue, float64(count),
+			append([]string{endpoint, event}, labels...)...)
+	}
+}
@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAdminCollector(t *testing.T) {
+	cases := map[NodeType]struct {
+		nodeType string
+		typeText string
+		typeNum  int
+		shardId  string
+	}{
+		Lookup:  {"Lookup", "Lookup", 2, ""},
+		Seed:    {"Seed", "Seed", 1, ""},
+		SeedPub: {"Seed", "Seed", 1, ""},
+		SeedPrv: {"Seed", "Seed", 1, ""},
+		Normal:  {"Shard Node of shard 2", "ShardNode", 4, "2"},
+		DSGuard: {"DS Node", "DSNode", 3, ""},
+	}
+	for _, nt := range nodeTypes {
+		tc := cases[nt]
+		t.Run(nt.String(), func(t *testing.T) {
+			assert := asserting.New(t)
+			server := jsonrpctest.NewTCPServer()
+			defer server.Close()
+			server.SetResult("GetNodeType", tc.nodeType)
+			server.SetResult("GetCurrentMiniEpoch", "1934")
+			server.SetResult("GetCurrentDSEpoch", "20")
+			server.SetResult("GetPrevDifficulty", 5)
+			server.SetResult("GetPrevDSDifficulty", 10)
+
+			constants := testConstants(testOptions(t, "--admin", server.Addr()), nt)
+			c := NewAdminCollector(constants)
+			expected := fmt.Sprintf(`
+# HELP admin_server_up Admin JsonRPC server (status server) up and running
+# TYPE admin_server_up gauge
+admin_server_up%s 1
+# HELP node_type Zilliqa network node type
+# TYPE node_type gauge
+node_type%s %d
+# HELP epoch Current TX block number of the node
+# TYPE epoch gauge
+epoch%s 1934
+# HELP ds_epoch Current DS block number of the node
+# TYPE ds_epoch gauge
+ds_epoch%s 20
+# HELP difficulty The minimum shard difficulty of the previous block
+# TYPE difficulty gauge
+difficulty%s 5
+# HELP ds_difficulty The minimum DS difficulty of the previous block
+# TYPE ds_difficulty gauge
+ds_difficulty%s 10
+`,
+				labelText(constants, "endpoint", server.Addr()),
+				labelText(constants, "text", tc.typeText), tc.typeNum,
+				labelText(constants), labelText(constants), labelText(constants), labelText(constants),
+			)
+			names := []string{"admin_server_up", "node_type", "epoch", "ds_epoch", "difficulty", "ds_difficulty", "shard_id"}
+			if tc.shardId != "" {
+				expected += fmt.Sprintf(`
+# HELP shard_id Shard ID of the shard of current node
+# TYPE shard_id gauge
+shard_id%s %s
+`, labelText(constants), tc.shardId)
+			}
+			assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), names...))
+		})
+	}
+}
+
+func TestAdminCollectorServerDown(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	server.SetResult("GetNodeType", "DS Node")
+	addr := server.Addr()
+	server.Close()
+
+	constants := testConstants(testOptions(t, "--admin", addr, "--rpc-retries", "0", "--rpc-breaker-failures", "1"), DSGuard)
+	c := NewAdminCollector(constants)
+	expected := fmt.Sprintf(`
+# HELP admin_server_up Admin JsonRPC server (status server) up and running
+# TYPE admin_server_up gauge
+admin_server_up%s 0
+# HELP admin_circuit_breaker_state Circuit breaker state of admin JsonRPC client, 1 for the current state
+# TYPE admin_circuit_breaker_state gauge
+admin_circuit_breaker_state%s 0
+admin_circuit_breaker_state%s 0
+admin_circuit_breaker_state%s 1
+`,
+		labelText(constants, "endpoint", addr),
+		labelText(constants, "endpoint", addr, "state", "closed"),
+		labelText(constants, "endpoint", addr, "state", "half_open"),
+		labelText(constants, "endpoint", addr, "state", "open"),
+	)
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"admin_server_up", "admin_circuit_breaker_state", "epoch"))
+}
+
+func TestAdminCollectorPartialFailure(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("GetNodeType", "DS Node")
+	server.SetResult("GetCurrentMiniEpoch", "1934")
+
+	constants := testConstants(testOptions(t, "--admin", server.Addr()), DSGuard)
+	c := NewAdminCollector(constants)
+	// methods not answered by the server are left out
+	expected := fmt.Sprintf(`
+# HELP epoch Current TX block number of the node
+# TYPE epoch gauge
+epoch%s 1934
+`, labelText(constants))
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "epoch", "ds_epoch", "difficulty"))
+}
+
+func TestAdminCollectorUnixSocket(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "collector")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "status.sock"))
+	assert.NoError(err)
+	server := jsonrpctest.NewTCPServerWithListener(l)
+	defer server.Close()
+	server.SetResult("GetNodeType", "Shard Node of shard 1")
+
+	endpoint := "unix://" + l.Addr().String()
+	constants := testConstants(testOptions(t, "--admin", endpoint), Normal)
+	c := NewAdminCollector(constants)
+	expected := fmt.Sprintf(`
+# HELP admin_server_up Admin JsonRPC server (status server) up and running
+# TYPE admin_server_up gauge
+admin_server_up%s 1
+# HELP shard_id Shard ID of the shard of current node
+# TYPE shard_id gauge
+shard_id%s 1
+`, labelText(constants, "endpoint", endpoint), labelText(constants))
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "admin_server_up", "shard_id"))
+}
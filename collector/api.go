@@ -1,11 +1,14 @@
 package collector
 
 import (
+	"context"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
 	"github.com/zilliqa/zilliqa-exporter/utils"
 	"strconv"
-	"sync"
 	"time"
 )
 
@@ -13,9 +16,11 @@ import (
 type APICollector struct {
 	options   *Options
 	constants *Constants
+	client    *apiclient.Client
 
 	// is jsonrpc api server up
-	apiServerUp *prometheus.Desc
+	apiServerUp  *prometheus.Desc
+	breakerState *prometheus.Desc
 
 	// block-chain related
 	// from GetBlockchainInfo
@@ -42,6 +47,18 @@ type APICollector struct {
 	latestDsBlockTimestamp *prometheus.Desc
 
 	apiServerDetected bool
+
+	// methods called besides GetBlockchainInfo, all of APIMethods if nil
+	methods map[apiclient.MethodName]bool
+}
+
+// APIMethods are the methods called by APICollector besides GetBlockchainInfo, which is always called
+var APIMethods = []apiclient.MethodName{
+	apiclient.GetPrevDifficulty,
+	apiclient.GetPrevDSDifficulty,
+	apiclient.GetNetworkId,
+	apiclient.GetLatestTxBlock,
+	apiclient.GetLatestDsBlock,
 }
 
 func NewAPICollector(constants *Constants) *APICollector {
@@ -49,10 +66,15 @@ func NewAPICollector(constants *Constants) *APICollector {
 	return &APICollector{
 		options:   constants.options,
 		constants: constants,
+		client:    constants.options.GetAPIClient(),
 		apiServerUp: prometheus.NewDesc(
 			"api_server_up", "JsonRPC API server up and running",
 			append([]string{"endpoint"}, commonLabels...), nil,
 		),
+		breakerState: prometheus.NewDesc(
+			"api_circuit_breaker_state", "Circuit breaker state of JsonRPC API client, 1 for the current state",
+			append([]string{"endpoint", "state"}, commonLabels...), nil,
+		),
 		epoch: prometheus.NewDesc(
 			"epoch", "Current TX block number of the node",
 			commonLabels, nil,
@@ -129,6 +151,7 @@ func (c *APICollector) Describe(ch chan<- *prometheus.Desc) {
 		return
 	}
 	ch <- c.apiServerUp
+	ch <- c.breakerState
 	ch <- c.epoch
 	ch <- c.dsEpoch
 	ch <- c.transactionRate
@@ -145,9 +168,15 @@ func (c *APICollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.dsDifficulty
 	ch <- c.networkID
 	ch <- c.latestTxBlockTimestamp
+	ch <- c.latestDsBlockTimestamp
 }
 
 func (c *APICollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects from API, the batch is canceled when ctx is done
+func (c *APICollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	labels := c.constants.CommonLabelValues()
 	if c.constants.NodeType() == UnknownNodeType {
 		log.WithField("endpoint", c.options.APIAddr()).Debug("node type unknown, try to access API Server")
@@ -160,15 +189,42 @@ func (c *APICollector) Collect(ch chan<- prometheus.Metric) {
 		return
 	}
 	log.Debug("enter api collector")
-	cli := c.options.GetAPIClient()
+	cli := c.client
 	if cli == nil {
 		log.Error("API endpoint not set")
 		return
 	}
-	log.Debug("start GetBlockchainInfo")
-	info, err := cli.GetBlockchainInfo()
+	defer collectBreakerState(ch, c.breakerState, cli.Breaker(), c.options.APIEndpoint(), labels)
+	ctx, cancel := context.WithTimeout(ctx, c.options.RPCTimeout())
+	defer cancel()
+
+	infoReq := apiclient.NewGetBlockchainInfoReq()
+	requests := []*jsonrpc.Request{infoReq}
+	newReq := func(method apiclient.MethodName) *jsonrpc.Request {
+		if c.methods != nil && !c.methods[method] {
+			return nil
+		}
+		req := apiclient.NewReq(method, nil)
+		requests = append(requests, req)
+		return req
+	}
+	diffReq := newReq(apiclient.GetPrevDifficulty)
+	dsDiffReq := newReq(apiclient.GetPrevDSDifficulty)
+	netIDReq := newReq(apiclient.GetNetworkId)
+	txBlockReq := newReq(apiclient.GetLatestTxBlock)
+	dsBlockReq := newReq(apiclient.GetLatestDsBlock)
+	log.Debug("batch GetBlockchainInfo, GetPrevDifficulty, GetPrevDSDifficulty, GetNetworkId, GetLatestTxBlock, GetLatestDsBlock from API")
+	batch, err := cli.CallBatchContext(ctx, requests...)
+	var info *core.BlockchainInfo
+	if err == nil {
+		var resp *jsonrpc.Response
+		resp, err = batch.Get(infoReq)
+		if err == nil {
+			info, err = apiclient.ParseBlockchainInfo(resp)
+		}
+	}
 	if err != nil {
-		log.WithError(err).Error("error while getting blockchain info")
+		logRPCError(err, "error while getting blockchain info")
 		ch <- prometheus.MustNewConstMetric(c.apiServerUp, prometheus.GaugeValue, float64(0),
 			append([]string{c.options.APIEndpoint()}, labels...)...)
 		return
@@ -197,80 +253,56 @@ func (c *APICollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.shardingPeers, prometheus.GaugeValue, float64(peers),
 			append([]string{strconv.Itoa(i)}, labels...)...)
 	}
-	log.Debug("done GetBlockchainInfo")
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		log.Debug("start GetPrevDifficulty")
-		defer wg.Done()
-		resp, err := cli.GetPrevDifficulty()
+	if diffReq != nil {
+		diff, err := batchFloat64(batch, diffReq)
 		if err != nil {
 			log.WithError(err).Error("fail to GetPrevDifficulty")
-			return
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.difficulty, prometheus.GaugeValue, diff, labels...)
 		}
-		ch <- prometheus.MustNewConstMetric(c.difficulty, prometheus.GaugeValue, float64(resp), labels...)
-		log.Debug("done GetPrevDifficulty")
-	}()
+	}
 
-	wg.Add(1)
-	go func() {
-		log.Debug("start GetPrevDSDifficulty")
-		defer wg.Done()
-		resp, err := cli.GetPrevDSDifficulty()
+	if dsDiffReq != nil {
+		dsDiff, err := batchFloat64(batch, dsDiffReq)
 		if err != nil {
 			log.WithError(err).Error("fail to GetPrevDSDifficulty")
-			return
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.dsDifficulty, prometheus.GaugeValue, dsDiff, labels...)
 		}
-		ch <- prometheus.MustNewConstMetric(c.dsDifficulty, prometheus.GaugeValue, float64(resp), labels...)
-		log.Debug("done GetPrevDSDifficulty")
-	}()
+	}
 
-	wg.Add(1)
-	go func() {
-		log.Debug("start GetNetworkId")
-		defer wg.Done()
-		resp, err := cli.GetNetworkId()
+	if netIDReq != nil {
+		netID, err := batchString(batch, netIDReq)
 		if err != nil {
 			log.WithError(err).Error("fail to GetNetworkId")
-		}
-		netID, err := strconv.ParseFloat(resp, 64)
-		if err != nil {
+		} else if id, err := strconv.ParseFloat(netID, 64); err != nil {
 			log.WithError(err).Error("fail to parse GetNetworkId as number")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.networkID, prometheus.GaugeValue, id, labels...)
 		}
-		ch <- prometheus.MustNewConstMetric(c.networkID, prometheus.GaugeValue, netID, labels...)
-		log.Debug("done GetNetworkId")
-	}()
-	wg.Add(1)
-	go func() {
-		log.Debug("start GetLatestTxBlock info")
-		defer wg.Done()
-		block, err := cli.GetLatestTxBlock()
-		if err != nil {
-			log.WithError(err).Error("fail to GetLatestTxBlock")
-		}
-		ts, err := strconv.ParseFloat(block.Header.Timestamp, 64)
-		if err != nil {
-			log.WithError(err).WithField("block", block).Error("fail to parse LatestTxBlock.Header.Timestamp as number")
-		}
-		ch <- prometheus.MustNewConstMetric(c.latestTxBlockTimestamp, prometheus.GaugeValue, ts/1000, labels...)
-		log.Debug("done GetLatestTxBlock")
-	}()
-	wg.Add(1)
-	go func() {
-		log.Debug("start GetLatestDsBlock info")
-		defer wg.Done()
-		block, err := cli.GetLatestDsBlock()
+	}
+
+	if txBlockReq != nil {
+		txBlock, err := batchTxBlock(batch, txBlockReq)
 		if err != nil {
 			log.WithError(err).Error("fail to GetLatestTxBlock")
+		} else if ts, err := strconv.ParseFloat(txBlock.Header.Timestamp, 64); err != nil {
+			log.WithError(err).WithField("block", txBlock).Error("fail to parse LatestTxBlock.Header.Timestamp as number")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.latestTxBlockTimestamp, prometheus.GaugeValue, ts/1000, labels...)
 		}
-		ts, err := strconv.ParseFloat(block.Header.Timestamp, 64)
+	}
+
+	if dsBlockReq != nil {
+		dsBlock, err := batchDSBlock(batch, dsBlockReq)
 		if err != nil {
-			log.WithError(err).WithField("block", block).Error("fail to parse LatestDsBlock.Header.Timestamp as number")
+			log.WithError(err).Error("fail to GetLatestDsBlock")
+		} else if ts, err := strconv.ParseFloat(dsBlock.Header.Timestamp, 64); err != nil {
+			log.WithError(err).WithField("block", dsBlock).Error("fail to parse LatestDsBlock.Header.Timestamp as number")
+		} else {
+			ch <- prometheus.MustNewConstMetric(c.latestDsBlockTimestamp, prometheus.GaugeValue, ts/1000, labels...)
 		}
-		ch <- prometheus.MustNewConstMetric(c.latestDsBlockTimestamp, prometheus.GaugeValue, ts/1000, labels...)
-		log.Debug("done GetLatestDsBlock")
-	}()
-	wg.Wait()
+	}
 	log.Debug("exit api collector")
 }
@@ -3,104 +3,94 @@ package collector
 import (
 	"context"
 	"encoding/json"
-	"github.com/Zilliqa/gozilliqa-sdk/provider"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-// some
+// ScheduledCollector watches states of contracts on their schedules, and exports the results of the latest runs
 type ScheduledCollector struct {
 	options   *Options
 	constants *Constants
+	client    *apiclient.Client
+	contracts []ContractWatch
 
 	// props
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
-	// TODO: persistence-check
-	UDStateSize    prometheus.Gauge
-	UDStateEntries prometheus.Gauge
-}
+	mu     sync.Mutex
+	states map[string]*contractState // by contract name
 
-func NewScheduledCollector(option *Options, constants *Constants) *ScheduledCollector {
-	c := &ScheduledCollector{options: option, constants: constants}
-	return c
+	stateSize  *prometheus.Desc
+	mapEntries *prometheus.Desc
+	field      *prometheus.Desc
 }
 
-func (s *ScheduledCollector) Init(register prometheus.Registerer) {
-	//commonLabels := s.constants.CommonLabels()
-	s.ctx, s.cancel = context.WithCancel(context.Background())
-	if s.options.IsMainNet {
-		s.UDStateSize = prometheus.NewGauge(prometheus.GaugeOpts{
-			Name: "ud_state_size",
-			Help: "State data size of unstoppable domain contract",
-		})
-		s.UDStateEntries = prometheus.NewGauge(prometheus.GaugeOpts{
-			Name: "ud_state_entries",
-			Help: "State entries count of unstoppable domain contract",
-		})
-		register.MustRegister(s.UDStateSize, s.UDStateEntries)
-	}
+type contractState struct {
+	size    int
+	entries map[string]int // by map path
+	fields  []contractFieldValue
 }
 
-func (s *ScheduledCollector) GetUDContractStateSizeRecords() (int, int, error) {
-	var address = "9611c53BE6d1b32058b2747bdeCECed7e1216793"
-	api := provider.NewProvider(s.options.APIEndpoint())
-	resp, err := api.GetSmartContractState(address)
-	if err != nil {
-		return 0, 0, err
-	}
-	js, err := json.Marshal(resp.Result)
-	if err != nil {
-		return 0, 0, err
-	}
-	size := len(js)
-	var result map[string]json.RawMessage
-	err = json.Unmarshal(js, &result)
-	if err != nil {
-		return 0, 0, err
-	}
-	records, _ := result["records"]
-	var entries map[string]interface{}
-	err = json.Unmarshal(records, &entries)
-	if err != nil {
-		return 0, 0, err
-	}
-	return size, len(entries), nil
+type contractFieldValue struct {
+	name  string
+	key   string // keys matched by wildcards of path, joined by comma
+	value float64
 }
 
-func (s *ScheduledCollector) CollectGetUDContractStateSizeRecords() {
-	size, records, err := s.GetUDContractStateSizeRecords()
-	if err != nil {
-		log.WithError(err).Error("fail to get UD Contract state")
+func NewScheduledCollector(constants *Constants, contracts []ContractWatch) *ScheduledCollector {
+	commonLabels := constants.CommonLabels()
+	return &ScheduledCollector{
+		options:   constants.options,
+		constants: constants,
+		client:    constants.options.GetAPIClient(),
+		contracts: contracts,
+		states:    make(map[string]*contractState),
+		stateSize: prometheus.NewDesc(
+			"contract_state_size_bytes", "Size of state json of the contract",
+			append([]string{"contract", "address"}, commonLabels...), nil,
+		),
+		mapEntries: prometheus.NewDesc(
+			"contract_state_map_entries", "Entries of a map in state of the contract",
+			append([]string{"contract", "address", "map"}, commonLabels...), nil,
+		),
+		field: prometheus.NewDesc(
+			"contract_state_field", "Scalar field in state of the contract",
+			append([]string{"contract", "address", "field", "key"}, commonLabels...), nil,
+		),
 	}
-	s.UDStateSize.Set(float64(size))
-	s.UDStateEntries.Set(float64(records))
-}
-
-type ScheduleTask struct {
-	fun      func()
-	interval time.Duration
 }
 
+// Start watches every contract on its schedule until Stop is called
 func (s *ScheduledCollector) Start() {
-	var schedules []ScheduleTask
-
-	if s.options.IsMainNet {
-		schedules = append(schedules, ScheduleTask{s.CollectGetUDContractStateSizeRecords, 1 * time.Hour})
+	if len(s.contracts) == 0 {
+		return
 	}
-
-	for _, t := range schedules {
+	if s.client == nil {
+		log.Error("API endpoint not set, contract states are not watched")
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, contract := range s.contracts {
+		contract := contract
+		log.WithField("contract", contract.Name).WithField("interval", contract.Interval).Info("start watching contract state")
+		s.wg.Add(1)
 		go func() {
-			s.wg.Add(1)
 			defer s.wg.Done()
+			ticker := time.NewTicker(contract.Interval)
+			defer ticker.Stop()
 			for {
+				s.watch(contract)
 				select {
-				case <-time.After(t.interval):
-					t.fun()
+				case <-ticker.C:
 				case <-s.ctx.Done():
 					return
 				}
@@ -113,4 +103,163 @@ func (s *ScheduledCollector) Stop() {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	s.wg.Wait()
+}
+
+func (s *ScheduledCollector) watch(contract ContractWatch) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.options.RPCTimeout())
+	defer cancel()
+	raw, err := s.client.GetSmartContractStateContext(ctx, contract.Address)
+	if err != nil {
+		logRPCError(err, "fail to get state of contract "+contract.Name)
+		return
+	}
+	state, err := parseContractState(contract, raw)
+	if err != nil {
+		log.WithError(err).WithField("contract", contract.Name).Error("fail to parse contract state")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[contract.Name] = state
+}
+
+func parseContractState(contract ContractWatch, raw json.RawMessage) (*contractState, error) {
+	var root interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	state := &contractState{size: len(raw), entries: make(map[string]int)}
+	for _, path := range contract.Maps {
+		values := lookupPath(root, path)
+		if len(values) != 1 {
+			return nil, errors.Errorf("map %s not found", path)
+		}
+		switch m := values[0].value.(type) {
+		case map[string]interface{}:
+			state.entries[path] = len(m)
+		case []interface{}:
+			state.entries[path] = len(m)
+		default:
+			return nil, errors.Errorf("%s is not a map", path)
+		}
+	}
+	for _, field := range contract.Fields {
+		for _, v := range lookupPath(root, field.Path) {
+			value, ok := scalarValue(v.value)
+			if !ok {
+				log.WithField("contract", contract.Name).WithField("path", field.Path).WithField("key", v.key).
+					Debug("skip non scalar field of contract state")
+				continue
+			}
+			state.fields = append(state.fields, contractFieldValue{name: field.Name, key: v.key, value: value})
+		}
+	}
+	return state, nil
+}
+
+type pathValue struct {
+	key   string
+	value interface{}
+}
+
+// lookupPath returns values at a JSON-path-style path like "$.balances.*" in root,
+// * matches every key of a map or index of a list, and matched keys are joined as key of the value
+func lookupPath(root interface{}, path string) []pathValue {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+	var values []pathValue
+	var walk func(node interface{}, segments []string, keys []string)
+	walk = func(node interface{}, segments []string, keys []string) {
+		if len(segments) == 0 {
+			values = append(values, pathValue{key: strings.Join(keys, ","), value: node})
+			return
+		}
+		segment, rest := segments[0], segments[1:]
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if segment != "*" {
+				if child, ok := n[segment]; ok {
+					walk(child, rest, keys)
+				}
+				return
+			}
+			names := make([]string, 0, len(n))
+			for name := range n {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				walk(n[name], rest, append(keys[:len(keys):len(keys)], name))
+			}
+		case []interface{}:
+			if segment != "*" {
+				if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(n) {
+					walk(n[i], rest, keys)
+				}
+				return
+			}
+			for i, child := range n {
+				walk(child, rest, append(keys[:len(keys):len(keys)], strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(root, segments, nil)
+	return values
+}
+
+// scalarValue converts numbers, numeric strings (e.g. Uint128) and booleans (including scilla Bool) to float
+func scalarValue(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case map[string]interface{}:
+		// scilla Bool is an ADT of constructor True or False
+		switch value["constructor"] {
+		case "True":
+			return 1, true
+		case "False":
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func (s *ScheduledCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.stateSize
+	ch <- s.mapEntries
+	ch <- s.field
+}
+
+func (s *ScheduledCollector) Collect(ch chan<- prometheus.Metric) {
+	labels := s.constants.CommonLabelValues()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, contract := range s.contracts {
+		state, ok := s.states[contract.Name]
+		if !ok {
+			continue
+		}
+		contractLabels := append([]string{contract.Name, contract.Address}, labels...)
+		ch <- prometheus.MustNewConstMetric(s.stateSize, prometheus.GaugeValue, float64(state.size), contractLabels...)
+		for path, entries := range state.entries {
+			ch <- prometheus.MustNewConstMetric(s.mapEntries, prometheus.GaugeValue, float64(entries),
+				append([]string{contract.Name, contract.Address, path}, labels...)...)
+		}
+		for _, field := range state.fields {
+			ch <- prometheus.MustNewConstMetric(s.field, prometheus.GaugeValue, field.value,
+				append([]string{contract.Name, contract.Address, field.name, field.key}, labels...)...)
+		}
+	}
 }
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testContractState = `{
+  "_balance": "1000",
+  "total_supply": "5000000",
+  "paused": {"constructor": "False", "argtypes": [], "arguments": []},
+  "owner": "0x1234567890123456789012345678901234567890",
+  "balances": {"0xaa": "10", "0xbb": "20"},
+  "allowances": {"0xaa": {"0xbb": "1"}, "0xcc": {"0xaa": "2", "0xbb": "3"}}
+}`
+
+func TestLookupPath(t *testing.T) {
+	assert := asserting.New(t)
+	state, err := parseContractState(ContractWatch{
+		Maps: []string{"balances", "$.allowances"},
+		Fields: []ContractField{
+			{Name: "supply", Path: "$.total_supply"},
+			{Name: "paused", Path: "paused"},
+			{Name: "owner", Path: "owner"},
+			{Name: "balance", Path: "$.balances.*"},
+			{Name: "allowance", Path: "allowances.*.*"},
+			{Name: "missing", Path: "missing.*"},
+		},
+	}, []byte(testContractState))
+	assert.NoError(err)
+	assert.Equal(map[string]int{"balances": 2, "$.allowances": 2}, state.entries)
+	assert.Equal([]contractFieldValue{
+		{name: "supply", value: 5000000},
+		{name: "paused", value: 0},
+		{name: "balance", key: "0xaa", value: 10},
+		{name: "balance", key: "0xbb", value: 20},
+		{name: "allowance", key: "0xaa,0xbb", value: 1},
+		{name: "allowance", key: "0xcc,0xaa", value: 2},
+		{name: "allowance", key: "0xcc,0xbb", value: 3},
+	}, state.fields)
+
+	_, err = parseContractState(ContractWatch{Maps: []string{"total_supply"}}, []byte(testContractState))
+	assert.Error(err)
+}
+
+func TestScheduledCollector(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	server.SetResult("GetSmartContractState", json.RawMessage(testContractState))
+
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	c := NewScheduledCollector(constants, []ContractWatch{{
+		Name:     "token",
+		Address:  "abcd",
+		Interval: time.Hour,
+		Maps:     []string{"balances"},
+		Fields:   []ContractField{{Name: "balance", Path: "balances.*"}},
+	}})
+	assert.Equal(0, testutil.CollectAndCount(c))
+	c.Start()
+	defer c.Stop()
+	assert.Eventually(func() bool {
+		return testutil.CollectAndCount(c) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// the server answers the state compacted
+	compacted := &bytes.Buffer{}
+	assert.NoError(json.Compact(compacted, []byte(testContractState)))
+	expected := fmt.Sprintf(`
+# HELP contract_state_size_bytes Size of state json of the contract
+# TYPE contract_state_size_bytes gauge
+contract_state_size_bytes%s %d
+# HELP contract_state_map_entries Entries of a map in state of the contract
+# TYPE contract_state_map_entries gauge
+contract_state_map_entries%s 2
+# HELP contract_state_field Scalar field in state of the contract
+# TYPE contract_state_field gauge
+contract_state_field%s 10
+contract_state_field%s 20
+`,
+		labelText(constants, "contract", "token", "address", "abcd"), compacted.Len(),
+		labelText(constants, "contract", "token", "address", "abcd", "map", "balances"),
+		labelText(constants, "contract", "token", "address", "abcd", "field", "balance", "key", "0xaa"),
+		labelText(constants, "contract", "token", "address", "abcd", "field", "balance", "key", "0xbb"),
+	)
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected)))
+}
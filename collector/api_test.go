@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestAPIServer() *jsonrpctest.HTTPServer {
+	server := jsonrpctest.NewHTTPServer()
+	server.SetResult("GetBlockchainInfo", map[string]interface{}{
+		"CurrentDSEpoch":    "20",
+		"CurrentMiniEpoch":  "1934",
+		"DSBlockRate":       0.001,
+		"NumDSBlocks":       "21",
+		"NumPeers":          60,
+		"NumTransactions":   "4000",
+		"NumTxBlocks":       "1935",
+		"NumTxnsDSEpoch":    "100",
+		"NumTxnsTxEpoch":    "3",
+		"ShardingStructure": map[string]interface{}{"NumPeers": []int{10, 12}},
+		"TransactionRate":   1.5,
+		"TxBlockRate":       0.02,
+	})
+	server.SetResult("GetPrevDifficulty", 5)
+	server.SetResult("GetPrevDSDifficulty", 10)
+	server.SetResult("GetNetworkId", "1")
+	server.SetResult("GetLatestTxBlock", map[string]interface{}{
+		"header": map[string]interface{}{"BlockNum": "1934", "Timestamp": "1600000000000000"},
+	})
+	server.SetResult("GetLatestDsBlock", map[string]interface{}{
+		"header": map[string]interface{}{"BlockNum": "20", "Timestamp": "1600000000000000"},
+	})
+	return server
+}
+
+func TestAPICollector(t *testing.T) {
+	for _, nt := range nodeTypes {
+		t.Run(nt.String(), func(t *testing.T) {
+			assert := asserting.New(t)
+			server := newTestAPIServer()
+			defer server.Close()
+			constants := testConstants(testOptions(t, "--api", server.URL), nt)
+			c := NewAPICollector(constants)
+			if !IsGeneralLookup(nt) {
+				assert.Equal(0, testutil.CollectAndCount(c))
+				assert.Equal(0, server.Calls("GetBlockchainInfo"))
+				return
+			}
+			l := labelText(constants)
+			expected := fmt.Sprintf(`
+# HELP api_server_up JsonRPC API server up and running
+# TYPE api_server_up gauge
+api_server_up%s 1
+# HELP epoch Current TX block number of the node
+# TYPE epoch gauge
+epoch%s 1934
+# HELP num_peers Peers count
+# TYPE num_peers gauge
+num_peers%s 60
+# HELP sharding_peers Peers count of every sharding
+# TYPE sharding_peers gauge
+sharding_peers%s 10
+sharding_peers%s 12
+# HELP ds_difficulty The minimum DS difficulty of the previous block
+# TYPE ds_difficulty gauge
+ds_difficulty%s 10
+# HELP network_id Network ID of current zilliqa network
+# TYPE network_id gauge
+network_id%s 1
+# HELP latest_txblock_timestamp The timestamp of the latest tx block
+# TYPE latest_txblock_timestamp gauge
+latest_txblock_timestamp%s 1.6e+12
+`,
+				labelText(constants, "endpoint", server.URL), l, l,
+				labelText(constants, "shard_index", "0"), labelText(constants, "shard_index", "1"),
+				l, l, l,
+			)
+			assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected),
+				"api_server_up", "epoch", "num_peers", "sharding_peers", "ds_difficulty", "network_id", "latest_txblock_timestamp"))
+			// one batch per scrape
+			assert.Equal(1, server.Calls("GetBlockchainInfo"))
+			assert.Equal(1, server.Calls("GetLatestDsBlock"))
+		})
+	}
+}
+
+func TestAPICollectorRateLimited(t *testing.T) {
+	assert := asserting.New(t)
+	server := newTestAPIServer()
+	defer server.Close()
+	server.SetStatus(http.StatusTooManyRequests)
+	constants := testConstants(testOptions(t, "--api", server.URL, "--rpc-retries", "0"), Lookup)
+	c := NewAPICollector(constants)
+	expected := fmt.Sprintf(`
+# HELP api_server_up JsonRPC API server up and running
+# TYPE api_server_up gauge
+api_server_up%s 0
+`, labelText(constants, "endpoint", server.URL))
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "api_server_up", "epoch"))
+}
@@ -0,0 +1,257 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var apiProbeDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// failure reason of a probe answered with a result not matching its expectations
+const probeUnexpectedResponse = "unexpected_response"
+
+// errUnexpectedResponse is returned when the result of a probe does not match its expectations
+var errUnexpectedResponse = errors.New("unexpected response")
+
+type apiProbeState struct {
+	success   bool
+	status    int // http status of the last call, 0 if no http response
+	durations *histogramStats
+	failures  map[string]uint64 // by reason
+}
+
+// APIProber calls probes of JsonRPC API on their schedules, as a synthetic client of the public API,
+// every call is sent on its own without retries, so that failures like rate limiting are seen as a client sees them
+type APIProber struct {
+	options   *Options
+	constants *Constants
+	client    *apiclient.Client
+	probes    []APIProbe
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	states map[string]*apiProbeState // by probe name
+
+	duration   *prometheus.Desc
+	success    *prometheus.Desc
+	httpStatus *prometheus.Desc
+	failures   *prometheus.Desc
+}
+
+func NewAPIProber(constants *Constants, probes []APIProbe) *APIProber {
+	commonLabels := constants.CommonLabels()
+	p := &APIProber{
+		options:   constants.options,
+		constants: constants,
+		probes:    probes,
+		states:    make(map[string]*apiProbeState),
+		duration: prometheus.NewDesc(
+			"api_probe_duration_seconds", "Duration of probes of JsonRPC API",
+			append([]string{"probe", "method"}, commonLabels...), nil,
+		),
+		success: prometheus.NewDesc(
+			"api_probe_success", "The last probe of JsonRPC API succeeded with an expected result",
+			append([]string{"probe", "method"}, commonLabels...), nil,
+		),
+		httpStatus: prometheus.NewDesc(
+			"api_probe_http_status", "HTTP status of the last probe of JsonRPC API, 0 if no http response",
+			append([]string{"probe", "method"}, commonLabels...), nil,
+		),
+		failures: prometheus.NewDesc(
+			"api_probe_failures_total", "Failed probes of JsonRPC API",
+			append([]string{"probe", "method", "reason"}, commonLabels...), nil,
+		),
+	}
+	if ep := constants.options.APIEndpoint(); ep != "" && len(probes) > 0 {
+		// without fallbacks, a failure of the endpoint is not hidden by an answer of another one
+		opts := constants.options.APIHTTPOptions()
+		opts.Fallbacks = nil
+		p.client = apiclient.New(ep, constants.options.RPCTimeout(), opts)
+		if constants.options.rpcObserver != nil {
+			p.client.SetObserver(constants.options.rpcObserver)
+		}
+	}
+	return p
+}
+
+// Start runs every probe on its schedule until Stop is called
+func (p *APIProber) Start() {
+	if len(p.probes) == 0 {
+		return
+	}
+	if p.client == nil {
+		log.Error("API endpoint not set, API probes are not run")
+		return
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	for _, probe := range p.probes {
+		probe := probe
+		log.WithField("probe", probe.Name).WithField("interval", probe.Interval).Info("start probing API")
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			ticker := time.NewTicker(probe.Interval)
+			defer ticker.Stop()
+			for {
+				p.probe(probe)
+				select {
+				case <-ticker.C:
+				case <-p.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+func (p *APIProber) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+func (p *APIProber) probe(probe APIProbe) {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = p.options.RPCTimeout()
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := p.client.CallContext(ctx, apiclient.NewReq(apiclient.MethodName(probe.Method), probe.Params))
+	duration := time.Since(start)
+	if p.ctx.Err() != nil {
+		// stopped, not a failure of the API
+		return
+	}
+	if err == nil {
+		err = checkProbeResult(probe.Expect, resp.RawResult())
+	}
+	if err != nil {
+		log.WithError(err).WithField("probe", probe.Name).Debug("probe failed")
+	}
+	p.observe(probe.Name, duration, err)
+}
+
+// checkProbeResult checks that result has values of the expected types at every path expected
+func checkProbeResult(expects []ProbeExpectation, result json.RawMessage) error {
+	if len(expects) == 0 {
+		return nil
+	}
+	var root interface{}
+	if err := json.Unmarshal(result, &root); err != nil {
+		return errors.Wrap(errUnexpectedResponse, err.Error())
+	}
+	for _, expect := range expects {
+		values := lookupPath(root, expect.Path)
+		if len(values) == 0 {
+			return errors.Wrapf(errUnexpectedResponse, "%s not found", expect.Path)
+		}
+		for _, v := range values {
+			if !matchProbeType(expect.Type, v.value) {
+				return errors.Wrapf(errUnexpectedResponse, "%s is not %s", expect.Path, expect.Type)
+			}
+		}
+	}
+	return nil
+}
+
+func matchProbeType(typ string, v interface{}) bool {
+	switch typ {
+	case "":
+		return true
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "bool":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// probeHTTPStatus returns http status of a call failed with err, JsonRPC responses are taken as 200
+func probeHTTPStatus(err error) int {
+	if err == nil || errors.Is(err, errUnexpectedResponse) {
+		return http.StatusOK
+	}
+	switch e := errors.Cause(err).(type) {
+	case jsonrpc.HTTPError:
+		return e.StatusCode
+	case jsonrpc.RPCError, *jsonrpc.RPCError:
+		return http.StatusOK
+	}
+	return 0
+}
+
+func (p *APIProber) observe(name string, duration time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state, ok := p.states[name]
+	if !ok {
+		state = &apiProbeState{durations: newHistogramStats(apiProbeDurationBuckets), failures: make(map[string]uint64)}
+		p.states[name] = state
+	}
+	state.durations.observe(apiProbeDurationBuckets, duration.Seconds())
+	state.success = err == nil
+	state.status = probeHTTPStatus(err)
+	if err != nil {
+		reason := jsonrpc.Outcome(err)
+		if errors.Is(err, errUnexpectedResponse) {
+			reason = probeUnexpectedResponse
+		}
+		state.failures[reason]++
+	}
+}
+
+func (p *APIProber) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.duration
+	ch <- p.success
+	ch <- p.httpStatus
+	ch <- p.failures
+}
+
+func (p *APIProber) Collect(ch chan<- prometheus.Metric) {
+	labels := p.constants.CommonLabelValues()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, probe := range p.probes {
+		state, ok := p.states[probe.Name]
+		if !ok {
+			continue
+		}
+		probeLabels := append([]string{probe.Name, probe.Method}, labels...)
+		ch <- state.durations.metric(p.duration, apiProbeDurationBuckets, probeLabels...)
+		var success float64
+		if state.success {
+			success = 1
+		}
+		ch <- prometheus.MustNewConstMetric(p.success, prometheus.GaugeValue, success, probeLabels...)
+		ch <- prometheus.MustNewConstMetric(p.httpStatus, prometheus.GaugeValue, float64(state.status), probeLabels...)
+		for reason, count := range state.failures {
+			ch <- prometheus.MustNewConstMetric(p.failures, prometheus.CounterValue, float64(count),
+				append([]string{probe.Name, probe.Method, reason}, labels...)...)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckProbeResult(t *testing.T) {
+	assert := asserting.New(t)
+	result := []byte(`{"balance": "100", "nonce": 3, "shards": [{"size": 600}, {"size": 300}]}`)
+	assert.NoError(checkProbeResult(nil, []byte("null")))
+	assert.NoError(checkProbeResult([]ProbeExpectation{
+		{Path: "$", Type: "object"},
+		{Path: "$.balance", Type: "string"},
+		{Path: "nonce", Type: "number"},
+		{Path: "shards", Type: "array"},
+		{Path: "shards.*.size", Type: "number"},
+		{Path: "shards.1"},
+	}, result))
+	assert.Error(checkProbeResult([]ProbeExpectation{{Path: "$.balance", Type: "number"}}, result))
+	assert.Error(checkProbeResult([]ProbeExpectation{{Path: "$.missing"}}, result))
+	assert.Error(checkProbeResult([]ProbeExpectation{{Path: "shards.*.size", Type: "string"}}, result))
+	assert.Error(checkProbeResult([]ProbeExpectation{{Path: "$"}}, []byte("invalid")))
+}
+
+func TestAPIProber(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	server.SetResult("GetBalance", map[string]interface{}{"balance": "100", "nonce": 3})
+	server.SetResult("GetTxBlock", map[string]interface{}{"header": "unexpected"})
+	server.SetError("GetTransaction", jsonrpc.RPCError{Code: -20, Message: "Txn Hash not Present"})
+
+	// a healthy fallback does not hide failures of the probed endpoint
+	fallback := jsonrpctest.NewHTTPServer()
+	defer fallback.Close()
+	fallback.SetResult("GetBalance", map[string]interface{}{"balance": "100", "nonce": 3})
+
+	constants := testConstants(testOptions(t, "--api", server.URL, "--api-fallback", fallback.URL), Lookup)
+	probes := []APIProbe{
+		{Name: "balance", Method: "GetBalance", Params: []interface{}{testGasAccount}, Interval: time.Hour,
+			Expect: []ProbeExpectation{{Path: "balance", Type: "string"}, {Path: "nonce", Type: "number"}}},
+		{Name: "tx_block", Method: "GetTxBlock", Params: []interface{}{"1"}, Interval: time.Hour,
+			Expect: []ProbeExpectation{{Path: "header.BlockNum", Type: "string"}}},
+		{Name: "transaction", Method: "GetTransaction", Interval: time.Hour},
+	}
+	p := NewAPIProber(constants, probes)
+	p.ctx = context.Background()
+	for _, probe := range probes {
+		p.probe(probe)
+	}
+	// rate limited
+	server.SetStatus(http.StatusTooManyRequests)
+	p.probe(probes[0])
+
+	probeLabels := func(probe, method string) string {
+		return labelText(constants, "probe", probe, "method", method)
+	}
+	expected := fmt.Sprintf(`
+# HELP api_probe_success The last probe of JsonRPC API succeeded with an expected result
+# TYPE api_probe_success gauge
+api_probe_success%s 0
+api_probe_success%s 0
+api_probe_success%s 0
+# HELP api_probe_http_status HTTP status of the last probe of JsonRPC API, 0 if no http response
+# TYPE api_probe_http_status gauge
+api_probe_http_status%s 429
+api_probe_http_status%s 200
+api_probe_http_status%s 200
+# HELP api_probe_failures_total Failed probes of JsonRPC API
+# TYPE api_probe_failures_total counter
+api_probe_failures_total%s 1
+api_probe_failures_total%s 1
+api_probe_failures_total%s 1
+`,
+		probeLabels("balance", "GetBalance"), probeLabels("tx_block", "GetTxBlock"), probeLabels("transaction", "GetTransaction"),
+		probeLabels("balance", "GetBalance"), probeLabels("tx_block", "GetTxBlock"), probeLabels("transaction", "GetTransaction"),
+		labelText(constants, "probe", "balance", "method", "GetBalance", "reason", "http_429"),
+		labelText(constants, "probe", "tx_block", "method", "GetTxBlock", "reason", "unexpected_response"),
+		labelText(constants, "probe", "transaction", "method", "GetTransaction", "reason", "-20"),
+	)
+	assert.NoError(testutil.CollectAndCompare(p, strings.NewReader(expected),
+		"api_probe_success", "api_probe_http_status", "api_probe_failures_total"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	assert.Equal(uint64(2), p.states["balance"].durations.count)
+	assert.Equal(0, fallback.Calls("GetBalance"))
+}
+
+func TestAPIProberStart(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	server.SetResult("GetNetworkId", "1")
+
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	p := NewAPIProber(constants, []APIProbe{{Name: "network", Method: "GetNetworkId", Interval: 10 * time.Millisecond}})
+	p.Start()
+	assert.Eventually(func() bool {
+		return server.Calls("GetNetworkId") >= 2
+	}, time.Second, 10*time.Millisecond)
+	p.Stop()
+	expected := fmt.Sprintf(`
+# HELP api_probe_success The last probe of JsonRPC API succeeded with an expected result
+# TYPE api_probe_success gauge
+api_probe_success%s 1
+`, labelText(constants, "probe", "network", "method", "GetNetworkId"))
+	assert.NoError(testutil.CollectAndCompare(p, strings.NewReader(expected), "api_probe_success"))
+}
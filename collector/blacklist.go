@@ -0,0 +1,316 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// operations of blacklist exclusion reconciler, as error labels
+const (
+	blacklistOpRead   = "read"
+	blacklistOpAdd    = "add"
+	blacklistOpRemove = "remove"
+	blacklistOpSave   = "save"
+)
+
+// RPC_INVALID_PARAMETER answered by the node when the ip is already excluded, or not excluded
+const blacklistErrCode = -8
+
+// endings of the messages answered by the node when the ip is already excluded, or not excluded, by operation
+var blacklistSettledMessages = map[string]string{
+	blacklistOpAdd:    "already present",
+	blacklistOpRemove: "not present",
+}
+
+// ReadBlacklistExclusions reads ips from path, one per line, blank lines and lines starting with # are ignored
+func ReadBlacklistExclusions(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to open blacklist exclusion file")
+	}
+	defer f.Close()
+	ips := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ip := net.ParseIP(line)
+		if ip == nil {
+			return nil, errors.Errorf("invalid ip %q at line %d of blacklist exclusion file", line, n)
+		}
+		ips[ip.String()] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "fail to read blacklist exclusion file")
+	}
+	return ips, nil
+}
+
+// writeBlacklistExclusions writes ips to path, one per line as read by ReadBlacklistExclusions,
+// through a temporary file so that a crash does not leave it half written
+func writeBlacklistExclusions(path string, ips map[string]struct{}) error {
+	lines := make([]string, 0, len(ips))
+	for ip := range ips {
+		lines = append(lines, ip+"\n")
+	}
+	sort.Strings(lines)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return errors.Wrap(err, "fail to write blacklist exclusion state file")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "fail to write blacklist exclusion state file")
+}
+
+// BlacklistReconciler keeps the ips in a file excluded from the blacklist of the node.
+// Exclusions are not persisted by the node, which may restart between two checks,
+// so every desired ip is applied again on every check, and answered as already excluded if still there.
+// Ips excluded by the exporter are kept in the state file if set, to remove the ones dropped while the exporter is down
+type BlacklistReconciler struct {
+	options   *Options
+	constants *Constants
+	client    *adminclient.Client
+	path      string
+	statePath string
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	mu      sync.Mutex
+	up      bool // admin api was up on the last check
+	desired map[string]struct{}
+	applied map[string]struct{} // ips excluded by the exporter and not removed since
+	errors  map[string]uint64   // by operation
+
+	desiredCount *prometheus.Desc
+	appliedCount *prometheus.Desc
+	errorsTotal  *prometheus.Desc
+}
+
+func NewBlacklistReconciler(constants *Constants) *BlacklistReconciler {
+	commonLabels := constants.CommonLabels()
+	return &BlacklistReconciler{
+		options:   constants.options,
+		constants: constants,
+		client:    constants.options.GetAdminOperationClient(),
+		path:      constants.options.blacklistExclusionFile,
+		statePath: constants.options.blacklistExclusionStateFile,
+		interval:  constants.options.blacklistExclusionInterval,
+		stop:      make(chan struct{}),
+		desired:   make(map[string]struct{}),
+		applied:   make(map[string]struct{}),
+		errors:    make(map[string]uint64),
+		desiredCount: prometheus.NewDesc(
+			"blacklist_exclusion_desired", "IPs in blacklist exclusion file",
+			commonLabels, nil,
+		),
+		appliedCount: prometheus.NewDesc(
+			"blacklist_exclusion_applied", "IPs excluded from blacklist of the node by the exporter",
+			commonLabels, nil,
+		),
+		errorsTotal: prometheus.NewDesc(
+			"blacklist_exclusion_errors_total", "Errors while reconciling blacklist exclusions",
+			append([]string{"operation"}, commonLabels...), nil,
+		),
+	}
+}
+
+// Start reconciles on start and every interval until Stop is called, it blocks
+func (r *BlacklistReconciler) Start() {
+	if r.path == "" || r.interval <= 0 || r.client == nil {
+		log.Info("blacklist exclusion reconciler disabled")
+		return
+	}
+	log.WithField("file", r.path).WithField("interval", r.interval).Info("start reconciling blacklist exclusions")
+	r.loadState()
+	r.reconcileOnce()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			log.Debug("stop reconciling blacklist exclusions")
+			return
+		case <-ticker.C:
+			r.reconcileOnce()
+		}
+	}
+}
+
+func (r *BlacklistReconciler) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+func (r *BlacklistReconciler) reconcileOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+
+	desired, err := ReadBlacklistExclusions(r.path)
+	if err != nil {
+		// keep reconciling with the last good file
+		log.WithError(err).Error("fail to read blacklist exclusions")
+		r.countError(blacklistOpRead)
+	} else {
+		r.mu.Lock()
+		r.desired = desired
+		r.mu.Unlock()
+	}
+
+	if _, err := r.client.GetNodeTypeContext(ctx); err != nil {
+		r.mu.Lock()
+		if r.up {
+			log.WithError(err).Warn("admin api down, blacklist exclusions will be applied again when it is back")
+		}
+		r.up = false
+		r.mu.Unlock()
+		return
+	}
+	r.mu.Lock()
+	if !r.up {
+		log.Info("admin api up, applying blacklist exclusions")
+	}
+	r.up = true
+	add, remove := r.diff()
+	r.mu.Unlock()
+
+	changed := false
+	for _, ip := range add {
+		ok, err := r.client.AddToBlacklistExclusionContext(ctx, ip)
+		if r.settled(blacklistOpAdd, ip, ok, err) {
+			r.mu.Lock()
+			if _, ok := r.applied[ip]; !ok {
+				r.applied[ip] = struct{}{}
+				changed = true
+			}
+			r.mu.Unlock()
+		}
+	}
+	for _, ip := range remove {
+		ok, err := r.client.RemoveFromBlacklistExclusionContext(ctx, ip)
+		if r.settled(blacklistOpRemove, ip, ok, err) {
+			r.mu.Lock()
+			delete(r.applied, ip)
+			changed = true
+			r.mu.Unlock()
+		}
+	}
+	if changed {
+		r.saveState()
+	}
+}
+
+// loadState reads ips excluded by the exporter before it was restarted from the state file
+func (r *BlacklistReconciler) loadState() {
+	if r.statePath == "" {
+		return
+	}
+	applied, err := ReadBlacklistExclusions(r.statePath)
+	if err != nil {
+		if !os.IsNotExist(errors.Cause(err)) {
+			log.WithError(err).Error("fail to read blacklist exclusion state file")
+			r.countError(blacklistOpRead)
+		}
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applied = applied
+}
+
+func (r *BlacklistReconciler) saveState() {
+	if r.statePath == "" {
+		return
+	}
+	r.mu.Lock()
+	applied := make(map[string]struct{}, len(r.applied))
+	for ip := range r.applied {
+		applied[ip] = struct{}{}
+	}
+	r.mu.Unlock()
+	if err := writeBlacklistExclusions(r.statePath, applied); err != nil {
+		log.WithError(err).Error("fail to save blacklist exclusion state")
+		r.countError(blacklistOpSave)
+	}
+}
+
+// diff returns every desired ip to add, and applied ips no longer desired to remove, r.mu should be held
+func (r *BlacklistReconciler) diff() (add, remove []string) {
+	for ip := range r.desired {
+		add = append(add, ip)
+	}
+	for ip := range r.applied {
+		if _, ok := r.desired[ip]; !ok {
+			remove = append(remove, ip)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return
+}
+
+// settled reports whether the node is in the desired state for ip after an operation,
+// either done with a true result or answered that the ip is already (or not) excluded.
+// Any other answer is counted as an error, and the ip is left for the next reconciliation
+func (r *BlacklistReconciler) settled(operation, ip string, ok bool, err error) bool {
+	entry := log.WithField("ip", ip).WithField("operation", operation)
+	if err == nil && ok {
+		entry.Info("blacklist exclusion reconciled")
+		return true
+	}
+	if err == nil {
+		err = errors.New("node answered false")
+	}
+	var rpcErr *jsonrpc.RPCError
+	switch e := errors.Cause(err).(type) {
+	case jsonrpc.RPCError:
+		rpcErr = &e
+	case *jsonrpc.RPCError:
+		rpcErr = e
+	}
+	if rpcErr != nil && rpcErr.Code == blacklistErrCode && strings.HasSuffix(rpcErr.Message, blacklistSettledMessages[operation]) {
+		entry.WithError(err).Debug("blacklist exclusion already reconciled")
+		return true
+	}
+	entry.WithError(err).Error("fail to reconcile blacklist exclusion")
+	r.countError(operation)
+	return false
+}
+
+func (r *BlacklistReconciler) countError(operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[operation]++
+}
+
+func (r *BlacklistReconciler) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.desiredCount
+	ch <- r.appliedCount
+	ch <- r.errorsTotal
+}
+
+func (r *BlacklistReconciler) Collect(ch chan<- prometheus.Metric) {
+	labels := r.constants.CommonLabelValues()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(r.desiredCount, prometheus.GaugeValue, float64(len(r.desired)), labels...)
+	ch <- prometheus.MustNewConstMetric(r.appliedCount, prometheus.GaugeValue, float64(len(r.applied)), labels...)
+	for _, operation := range []string{blacklistOpRead, blacklistOpAdd, blacklistOpRemove, blacklistOpSave} {
+		ch <- prometheus.MustNewConstMetric(r.errorsTotal, prometheus.CounterValue, float64(r.errors[operation]),
+			append([]string{operation}, labels...)...)
+	}
+}
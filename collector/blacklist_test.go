@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReadBlacklistExclusions(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "collector")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exclusions")
+
+	assert.NoError(ioutil.WriteFile(path, []byte("# trusted peers\n10.0.0.1\n\n 10.0.0.2 \n10.0.0.1\n"), 0644))
+	ips, err := ReadBlacklistExclusions(path)
+	assert.NoError(err)
+	assert.Equal(map[string]struct{}{"10.0.0.1": {}, "10.0.0.2": {}}, ips)
+
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\nlocalhost\n"), 0644))
+	_, err = ReadBlacklistExclusions(path)
+	assert.Error(err)
+}
+
+// blacklistNode is a fake node keeping blacklist exclusions in memory, lost on restart as on zilliqa
+type blacklistNode struct {
+	mu       sync.Mutex
+	excluded map[string]bool
+	flaky    int
+}
+
+func newBlacklistNode(server *jsonrpctest.TCPServer, excluded ...string) *blacklistNode {
+	n := &blacklistNode{excluded: make(map[string]bool)}
+	for _, ip := range excluded {
+		n.excluded[ip] = true
+	}
+	server.SetResult("GetNodeType", "DS Node")
+	server.SetHandler("AddToBlacklistExclusion", func(call int, params json.RawMessage) (interface{}, error) {
+		var ips []string
+		_ = json.Unmarshal(params, &ips)
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.excluded[ips[0]] {
+			return nil, jsonrpc.RPCError{Code: -8, Message: "Could not add IP Address in exclusion list, already present"}
+		}
+		if ips[0] == "10.0.0.3" {
+			// fails with an error, then with a false result
+			if n.flaky++; n.flaky == 1 {
+				return nil, jsonrpc.InternalError
+			} else if n.flaky == 2 {
+				return false, nil
+			}
+		}
+		n.excluded[ips[0]] = true
+		return true, nil
+	})
+	server.SetHandler("RemoveFromBlacklistExclusion", func(call int, params json.RawMessage) (interface{}, error) {
+		var ips []string
+		_ = json.Unmarshal(params, &ips)
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if !n.excluded[ips[0]] {
+			return nil, jsonrpc.RPCError{Code: -8, Message: "Could not remove IP Address from exclusion list, not present"}
+		}
+		delete(n.excluded, ips[0])
+		return true, nil
+	})
+	return n
+}
+
+func (n *blacklistNode) ips() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var ips []string
+	for ip := range n.excluded {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+func TestBlacklistReconciler(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "collector")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exclusions")
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n10.0.0.2\n"), 0644))
+
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	// 10.0.0.2 already excluded by the node
+	node := newBlacklistNode(server, "10.0.0.2")
+
+	constants := testConstants(testOptions(t, "--admin", server.Addr(), "--rpc-timeout", "1s",
+		"--blacklist-exclusion-file", path), DSGuard)
+	r := NewBlacklistReconciler(constants)
+	expect := func(desired, applied, readErrors, addErrors int) {
+		expected := fmt.Sprintf(`
+# HELP blacklist_exclusion_desired IPs in blacklist exclusion file
+# TYPE blacklist_exclusion_desired gauge
+blacklist_exclusion_desired%s %d
+# HELP blacklist_exclusion_applied IPs excluded from blacklist of the node by the exporter
+# TYPE blacklist_exclusion_applied gauge
+blacklist_exclusion_applied%s %d
+# HELP blacklist_exclusion_errors_total Errors while reconciling blacklist exclusions
+# TYPE blacklist_exclusion_errors_total counter
+blacklist_exclusion_errors_total%s %d
+blacklist_exclusion_errors_total%s %d
+blacklist_exclusion_errors_total%s 0
+blacklist_exclusion_errors_total%s 0
+`,
+			labelText(constants), desired, labelText(constants), applied,
+			labelText(constants, "operation", "add"), addErrors,
+			labelText(constants, "operation", "read"), readErrors,
+			labelText(constants, "operation", "remove"),
+			labelText(constants, "operation", "save"),
+		)
+		assert.NoError(testutil.CollectAndCompare(r, strings.NewReader(expected)))
+	}
+
+	r.reconcileOnce()
+	assert.Equal([]string{"10.0.0.1", "10.0.0.2"}, node.ips())
+	expect(2, 2, 0, 0)
+
+	// every desired ip is applied again on every check, answered as already excluded
+	r.reconcileOnce()
+	assert.Equal(4, server.Calls("AddToBlacklistExclusion"))
+	expect(2, 2, 0, 0)
+
+	// removed from file
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n"), 0644))
+	r.reconcileOnce()
+	assert.Equal(1, server.Calls("RemoveFromBlacklistExclusion"))
+	assert.Equal([]string{"10.0.0.1"}, node.ips())
+	expect(1, 1, 0, 0)
+
+	// broken file keeps last good state
+	assert.NoError(ioutil.WriteFile(path, []byte("not an ip\n"), 0644))
+	r.reconcileOnce()
+	expect(1, 1, 1, 0)
+
+	// admin api down skips the check
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n"), 0644))
+	server.DropConnections(1)
+	calls := server.Calls("AddToBlacklistExclusion")
+	r.reconcileOnce()
+	assert.Equal(calls, server.Calls("AddToBlacklistExclusion"))
+	expect(1, 1, 1, 0)
+
+	// other errors and false results are retried
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n10.0.0.3\n"), 0644))
+	r.reconcileOnce()
+	expect(2, 1, 1, 1)
+	r.reconcileOnce()
+	expect(2, 1, 1, 2)
+	r.reconcileOnce()
+	expect(2, 2, 1, 2)
+	assert.Equal([]string{"10.0.0.1", "10.0.0.3"}, node.ips())
+}
+
+func TestBlacklistReconcilerRestart(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "collector")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exclusions")
+	statePath := filepath.Join(dir, "state")
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n10.0.0.2\n"), 0644))
+
+	server := jsonrpctest.NewTCPServer()
+	addr := server.Addr()
+	newBlacklistNode(server)
+	options := testOptions(t, "--admin", addr, "--rpc-timeout", "1s",
+		"--blacklist-exclusion-file", path, "--blacklist-exclusion-state-file", statePath)
+	r := NewBlacklistReconciler(testConstants(options, DSGuard))
+	r.loadState()
+	r.reconcileOnce()
+	state, err := ReadBlacklistExclusions(statePath)
+	assert.NoError(err)
+	assert.Equal(map[string]struct{}{"10.0.0.1": {}, "10.0.0.2": {}}, state)
+
+	// the node restarts between two checks, losing its exclusions
+	server.Close()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = jsonrpctest.NewTCPServerWithListener(l)
+	defer server.Close()
+	node := newBlacklistNode(server)
+	r.reconcileOnce()
+	assert.Equal([]string{"10.0.0.1", "10.0.0.2"}, node.ips())
+
+	// 10.0.0.2 removed from the file while the exporter is down
+	assert.NoError(ioutil.WriteFile(path, []byte("10.0.0.1\n"), 0644))
+	r = NewBlacklistReconciler(testConstants(options, DSGuard))
+	r.loadState()
+	r.reconcileOnce()
+	assert.Equal([]string{"10.0.0.1"}, node.ips())
+	state, err = ReadBlacklistExclusions(statePath)
+	assert.NoError(err)
+	assert.Equal(map[string]struct{}{"10.0.0.1": {}}, state)
+}
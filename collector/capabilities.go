@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"sync"
+	"time"
+)
+
+// adminCapabilities caches admin methods answered by the node, probed again every ttl,
+// as the node may be upgraded without a restart of the exporter, or as soon as a supported method is not found.
+// Methods are probed with a batch of adminclient.QueryMethods, only MethodNotFound means unsupported
+type adminCapabilities struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	probed   map[adminclient.MethodName]bool // nil until probed, or after invalidated
+	probedAt time.Time
+}
+
+func newAdminCapabilities(ttl time.Duration) *adminCapabilities {
+	return &adminCapabilities{ttl: ttl, now: time.Now}
+}
+
+// supported returns support of query methods by the node, probing them if not probed in ttl
+func (a *adminCapabilities) supported(ctx context.Context, cli *adminclient.Client) (map[adminclient.MethodName]bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.probed != nil && (a.ttl <= 0 || a.now().Sub(a.probedAt) < a.ttl) {
+		return a.probed, nil
+	}
+
+	requests := make([]*jsonrpc.Request, 0, len(adminclient.QueryMethods))
+	for _, method := range adminclient.QueryMethods {
+		requests = append(requests, adminclient.NewReq(method, nil))
+	}
+	batch, err := cli.CallBatchContext(ctx, requests...)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to probe admin methods")
+	}
+	supported := make(map[adminclient.MethodName]bool, len(requests))
+	for i, req := range requests {
+		err := batch.Err(req)
+		if errors.Is(err, jsonrpc.ErrMissingResponse) {
+			// no answer is not a proof of anything, probe again later
+			return nil, errors.Wrapf(err, "no answer of %s while probing admin methods", req.Method)
+		}
+		supported[adminclient.QueryMethods[i]] = !errors.Is(err, jsonrpc.MethodNotFound)
+	}
+	a.probed, a.probedAt = supported, a.now()
+	log.WithField("supported", supported).Info("probed admin methods")
+	return supported, nil
+}
+
+// invalidate drops probed methods, a supported method was not found, so they are probed again on next call
+func (a *adminCapabilities) invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.probed != nil {
+		log.Info("admin method not found, probing admin methods again")
+	}
+	a.probed = nil
+}
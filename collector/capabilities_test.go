@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminCollectorCapabilities(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("GetNodeType", "Lookup")
+	server.SetResult("GetCurrentMiniEpoch", "1934")
+
+	constants := testConstants(testOptions(t, "--admin", server.Addr()), Lookup)
+	c := NewAdminCollector(constants)
+	now := time.Now()
+	c.capabilities.now = func() time.Time { return now }
+	expected := `
+# HELP admin_method_supported Whether the admin API method is supported by the node
+# TYPE admin_method_supported gauge
+`
+	for _, method := range adminclient.QueryMethods {
+		var value int
+		if method == adminclient.GetNodeType || method == adminclient.GetCurrentMiniEpoch {
+			value = 1
+		}
+		expected += fmt.Sprintf("admin_method_supported%s %d\n", labelText(constants, "method", string(method)), value)
+	}
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "admin_method_supported"))
+	testutil.CollectAndCount(c)
+	// probed once, unsupported methods are skipped afterwards
+	assert.Equal(1, server.Calls("GetCurrentDSEpoch"))
+	assert.Equal(3, server.Calls("GetCurrentMiniEpoch"))
+
+	// probed again after the interval, the node was upgraded
+	server.SetResult("GetCurrentDSEpoch", "20")
+	now = now.Add(9 * time.Minute)
+	testutil.CollectAndCount(c)
+	assert.Equal(1, server.Calls("GetCurrentDSEpoch"))
+	now = now.Add(time.Minute)
+	testutil.CollectAndCount(c)
+	assert.Equal(3, server.Calls("GetCurrentDSEpoch"))
+
+	// probed again as soon as a supported method is not found, the node was downgraded
+	server.SetError("GetCurrentDSEpoch", jsonrpc.MethodNotFound)
+	testutil.CollectAndCount(c)
+	assert.Equal(4, server.Calls("GetCurrentDSEpoch"))
+	testutil.CollectAndCount(c)
+	testutil.CollectAndCount(c)
+	assert.Equal(5, server.Calls("GetCurrentDSEpoch"))
+}
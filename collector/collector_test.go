@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/spf13/pflag"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testOptions returns options with flag defaults, overridden by args
+func testOptions(t *testing.T, args ...string) *Options {
+	options := &Options{}
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	options.BindFlags(set)
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return options
+}
+
+// testConstants returns constants of a node of type nt, without detecting from the environment
+func testConstants(options *Options, nt NodeType) *Constants {
+	return &Constants{options: options, nodeType: nt, ClusterName: "test", PodName: "test-" + nt.String() + "-0"}
+}
+
+// labelText formats common labels and extra name, value pairs as labels in text exposition format
+func labelText(c *Constants, extra ...string) string {
+	names := c.CommonLabels()
+	values := c.CommonLabelValues()
+	for i := 0; i+1 < len(extra); i += 2 {
+		names = append(names, extra[i])
+		values = append(values, extra[i+1])
+	}
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
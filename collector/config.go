@@ -0,0 +1,285 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/Zilliqa/gozilliqa-sdk/bech32"
+	"github.com/pkg/errors"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// UDContract is the contract of unstoppable domains on mainnet, watched with --mainnet
+var UDContract = ContractWatch{
+	Name:     "unstoppable_domains",
+	Address:  "9611c53BE6d1b32058b2747bdeCECed7e1216793",
+	Interval: time.Hour,
+	Maps:     []string{"records"},
+}
+
+var configNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Config is the configuration file of the exporter, set by --config
+type Config struct {
+	Contracts []ContractWatch `yaml:"contracts"`
+	Accounts  []AccountWatch  `yaml:"accounts"`
+	Probes    []APIProbe      `yaml:"probes"`
+	// modules of /probe by name, with targets allowed to be probed
+	Modules map[string]ProbeModule `yaml:"modules"`
+}
+
+// ContractWatch is a contract whose state is watched on schedule
+type ContractWatch struct {
+	Name     string        `yaml:"name"`
+	Address  string        `yaml:"address"`
+	Interval time.Duration `yaml:"interval"`
+	// paths of maps to count entries of
+	Maps []string `yaml:"maps"`
+	// scalar fields exported as gauges
+	Fields []ContractField `yaml:"fields"`
+}
+
+// ContractField is a scalar field of contract state, at a JSON-path-style path like "$.balances.*",
+// where * matches every key of a map or index of a list
+type ContractField struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// AccountWatch is an account whose balance and nonce are watched,
+// address is either hex or bech32 (zil1...)
+type AccountWatch struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+}
+
+// APIProbe is a JsonRPC API method called on schedule, with the shape of its result checked
+type APIProbe struct {
+	Name     string        `yaml:"name"`
+	Method   string        `yaml:"method"`
+	Params   []interface{} `yaml:"params"`
+	Interval time.Duration `yaml:"interval"`
+	// deadline of a call, --rpc-timeout if not set
+	Timeout time.Duration      `yaml:"timeout"`
+	Expect  []ProbeExpectation `yaml:"expect"`
+}
+
+// ProbeExpectation is a path expected in the result of a probe, in the same form as ContractField.Path.
+// Every value at the path should be of Type, one of string, number, bool, object and array, or anything if empty
+type ProbeExpectation struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type"`
+}
+
+// ProbeModule is how a target of /probe is probed, with the collector of Prober, one of api and admin
+type ProbeModule struct {
+	Prober string `yaml:"prober"`
+	// deadline of every call to the target, --rpc-timeout if not set
+	Timeout time.Duration `yaml:"timeout"`
+	// methods called besides GetBlockchainInfo of api or GetNodeType of admin, all methods if empty
+	Methods []string `yaml:"methods"`
+	// targets allowed to be probed, by host:port, or by host for any port. Other targets are refused
+	Targets []string `yaml:"targets"`
+}
+
+// Allowed reports whether target is one of the targets of the module
+func (m ProbeModule) Allowed(target string) bool {
+	addr := target
+	if m.Prober == "api" {
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			target = "http://" + target
+		}
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return false
+		}
+		addr = parsed.Host
+	}
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	for _, t := range m.Targets {
+		if t == addr || t == host {
+			return true
+		}
+	}
+	return false
+}
+
+var probeExpectationTypes = map[string]bool{"": true, "string": true, "number": true, "bool": true, "object": true, "array": true}
+
+// LoadConfig reads config from path, an empty config is returned if path is empty
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to read config")
+	}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, errors.Wrap(err, "fail to parse config")
+	}
+	if err := config.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	names := make(map[string]bool)
+	for i := range c.Contracts {
+		contract := &c.Contracts[i]
+		if !configNamePattern.MatchString(contract.Name) {
+			return errors.Errorf("contract name %q should match %s", contract.Name, configNamePattern)
+		}
+		if names[contract.Name] {
+			return errors.Errorf("duplicated contract name %q", contract.Name)
+		}
+		names[contract.Name] = true
+		contract.Address = strings.TrimPrefix(strings.TrimPrefix(contract.Address, "0x"), "0X")
+		if contract.Address == "" {
+			return errors.Errorf("address of contract %q not set", contract.Name)
+		}
+		if contract.Interval <= 0 {
+			contract.Interval = time.Hour
+		}
+		fields := make(map[string]bool)
+		for _, field := range contract.Fields {
+			if field.Name == "" || field.Path == "" {
+				return errors.Errorf("name and path of fields of contract %q should be set", contract.Name)
+			}
+			if fields[field.Name] {
+				return errors.Errorf("duplicated field name %q of contract %q", field.Name, contract.Name)
+			}
+			fields[field.Name] = true
+		}
+	}
+
+	names = make(map[string]bool)
+	for i := range c.Accounts {
+		account := &c.Accounts[i]
+		if !configNamePattern.MatchString(account.Name) {
+			return errors.Errorf("account name %q should match %s", account.Name, configNamePattern)
+		}
+		if names[account.Name] {
+			return errors.Errorf("duplicated account name %q", account.Name)
+		}
+		names[account.Name] = true
+		address, err := normalizeAddress(account.Address)
+		if err != nil {
+			return errors.Wrapf(err, "invalid address of account %q", account.Name)
+		}
+		account.Address = address
+	}
+
+	names = make(map[string]bool)
+	for i := range c.Probes {
+		probe := &c.Probes[i]
+		if !configNamePattern.MatchString(probe.Name) {
+			return errors.Errorf("probe name %q should match %s", probe.Name, configNamePattern)
+		}
+		if names[probe.Name] {
+			return errors.Errorf("duplicated probe name %q", probe.Name)
+		}
+		names[probe.Name] = true
+		if probe.Method == "" {
+			return errors.Errorf("method of probe %q not set", probe.Name)
+		}
+		// params are sent as json, maps decoded from yaml are keyed by interface{}
+		for i, param := range probe.Params {
+			probe.Params[i] = jsonValue(param)
+		}
+		if probe.Interval <= 0 {
+			probe.Interval = 30 * time.Second
+		}
+		for _, expect := range probe.Expect {
+			if expect.Path == "" {
+				return errors.Errorf("path of expectations of probe %q should be set", probe.Name)
+			}
+			if !probeExpectationTypes[expect.Type] {
+				return errors.Errorf("unknown type %q expected by probe %q", expect.Type, probe.Name)
+			}
+		}
+	}
+
+	for name, module := range c.Modules {
+		var known map[string]bool
+		switch module.Prober {
+		case "api":
+			known = methodSet(APIMethods)
+		case "admin":
+			known = methodSet(adminclient.QueryMethods)
+		default:
+			return errors.Errorf("prober of module %q should be api or admin, got %q", name, module.Prober)
+		}
+		if len(module.Targets) == 0 {
+			return errors.Errorf("targets of module %q not set", name)
+		}
+		for _, method := range module.Methods {
+			if !known[method] {
+				return errors.Errorf("method %q of module %q is not called by %s prober", method, name, module.Prober)
+			}
+		}
+	}
+	return nil
+}
+
+// methodSet returns names of methods as a set
+func methodSet(methods interface{}) map[string]bool {
+	set := make(map[string]bool)
+	switch methods := methods.(type) {
+	case []apiclient.MethodName:
+		for _, m := range methods {
+			set[string(m)] = true
+		}
+	case []adminclient.MethodName:
+		for _, m := range methods {
+			set[string(m)] = true
+		}
+	}
+	return set
+}
+
+// jsonValue converts maps decoded from yaml to maps with string keys recursively, to be marshaled as json
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range value {
+			value[i] = jsonValue(v)
+		}
+	}
+	return v
+}
+
+var hexAddressPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// normalizeAddress converts bech32 or hex address to lower case hex without 0x, as accepted by GetBalance
+func normalizeAddress(address string) (string, error) {
+	if strings.HasPrefix(address, "zil1") {
+		hex, err := bech32.FromBech32Addr(address)
+		if err != nil {
+			return "", err
+		}
+		address = hex
+	}
+	address = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X"))
+	if !hexAddressPattern.MatchString(address) {
+		return "", errors.Errorf("address %q is not 20 bytes hex", address)
+	}
+	return address, nil
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	asserting "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "config*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(content)
+	_ = f.Close()
+	return f.Name()
+}
+
+func TestLoadConfig(t *testing.T) {
+	assert := asserting.New(t)
+	config, err := LoadConfig("")
+	assert.NoError(err)
+	assert.Empty(config.Contracts)
+
+	path := writeTestConfig(t, `
+contracts:
+  - name: token
+    address: 0xABCD
+    interval: 5m
+    maps: [balances]
+    fields:
+      - name: total_supply
+        path: $.total_supply
+  - name: registry
+    address: 1234
+accounts:
+  - name: gas
+    address: zil1fwh4ltdguhde9s7nysnp33d5wye6uqpugufkz7
+  - name: reward
+    address: 0x448261915A80CDE9BDE7C7A791685200D3A0BF4E
+probes:
+  - name: balance
+    method: GetBalance
+    params: [4baf5fada8e5db92c3d3242618c5b47133ae003c, {options: [1]}]
+    timeout: 2s
+    expect:
+      - path: $.balance
+        type: string
+modules:
+  lookup:
+    prober: api
+    timeout: 3s
+    methods: [GetLatestTxBlock]
+    targets: [api.zilliqa.com]
+`)
+	defer os.Remove(path)
+	config, err = LoadConfig(path)
+	assert.NoError(err)
+	assert.Len(config.Contracts, 2)
+	assert.Equal("ABCD", config.Contracts[0].Address)
+	assert.Equal(5*time.Minute, config.Contracts[0].Interval)
+	assert.Equal([]ContractField{{Name: "total_supply", Path: "$.total_supply"}}, config.Contracts[0].Fields)
+	assert.Equal(time.Hour, config.Contracts[1].Interval)
+	assert.Equal([]AccountWatch{
+		{Name: "gas", Address: "4baf5fada8e5db92c3d3242618c5b47133ae003c"},
+		{Name: "reward", Address: "448261915a80cde9bde7c7a791685200d3a0bf4e"},
+	}, config.Accounts)
+	assert.Equal([]APIProbe{{
+		Name:     "balance",
+		Method:   "GetBalance",
+		Params:   []interface{}{"4baf5fada8e5db92c3d3242618c5b47133ae003c", map[string]interface{}{"options": []interface{}{1}}},
+		Interval: 30 * time.Second,
+		Timeout:  2 * time.Second,
+		Expect:   []ProbeExpectation{{Path: "$.balance", Type: "string"}},
+	}}, config.Probes)
+	assert.Equal(map[string]ProbeModule{
+		"lookup": {Prober: "api", Timeout: 3 * time.Second, Methods: []string{"GetLatestTxBlock"}, Targets: []string{"api.zilliqa.com"}},
+	}, config.Modules)
+
+	for _, invalid := range []string{
+		"contracts:\n  - name: bad-name\n    address: 1234\n",
+		"contracts:\n  - name: token\n  - name: token\n    address: 1234\n",
+		"contracts:\n  - name: token\n    address: 1234\n    unknown: 1\n",
+		"accounts:\n  - name: gas\n    address: 1234\n",
+		"probes:\n  - name: balance\n",
+		"probes:\n  - name: balance\n    method: GetBalance\n    expect:\n      - path: balance\n        type: int\n",
+		"accounts:\n  - name: gas\n    address: zil1fwh4ltdguhde9s7nysnp33d5wye6uqpugufkz8\n",
+		"modules:\n  lookup:\n    prober: websocket\n    targets: [api.zilliqa.com]\n",
+		"modules:\n  lookup:\n    prober: admin\n    methods: [GetLatestTxBlock]\n    targets: [127.0.0.1]\n",
+		"modules:\n  lookup:\n    prober: api\n",
+		"contracts:\n  - name: token\n    address: 1234\n    fields:\n      - name: a\n        path: a\n      - name: a\n        path: b\n",
+	} {
+		path := writeTestConfig(t, invalid)
+		_, err := LoadConfig(path)
+		assert.Error(err, invalid)
+		_ = os.Remove(path)
+	}
+
+	options := testOptions(t, "--mainnet")
+	config, err = options.LoadConfig()
+	assert.NoError(err)
+	assert.Equal([]ContractWatch{UDContract}, config.Contracts)
+}
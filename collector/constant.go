@@ -5,6 +5,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"github.com/zilliqa/zilliqa-exporter/utils"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -47,6 +48,8 @@ type Constants struct {
 	nodeType   NodeType
 	nodeIndex  int
 	p2pPort    uint32
+	pubKey     string
+	workDir    string // working directory of zilliqa process
 }
 
 func NewConstants(options *Options) *Constants {
@@ -149,6 +152,26 @@ func (c *Constants) P2PPort() uint32 {
 	return c.p2pPort
 }
 
+// PubKey returns public key of the node, from --pubkey or detected from cmdline of zilliqa process
+func (c *Constants) PubKey() string {
+	if c.options.pubKey != "" {
+		return c.options.pubKey
+	}
+	return c.pubKey
+}
+
+// ZilliqaConstantsPath returns path of constants.xml of the node, from --zilliqa-constants
+// or in working directory of zilliqa process
+func (c *Constants) ZilliqaConstantsPath() string {
+	if c.options.zilliqaConstants != "" {
+		return c.options.zilliqaConstants
+	}
+	if c.workDir == "" {
+		return ""
+	}
+	return filepath.Join(c.workDir, "constants.xml")
+}
+
 func nodeTypeIndexFromPodName(podName string) (NodeType, int) {
 	split := strings.Split(podName, "-") // xxx-TYPE-INDEX (generated pod name of stateful set)
 	if len(split) > 2 {
@@ -203,6 +226,10 @@ func (c *Constants) doDetectVars() {
 	} else if p := GetZilliqaMainProcess(c); p != nil {
 		cmdline, err = p.CmdlineSlice()
 		log.Debug("got cmdline from zilliqa process")
+		if cwd, err := p.Cwd(); err == nil {
+			c.workDir = cwd
+			log.Debug("got working directory of zilliqa process")
+		}
 	}
 	if cmdline == nil || len(cmdline) == 0 {
 		log.Debug("fail get cmdline")
@@ -222,6 +249,11 @@ func (c *Constants) doDetectVars() {
 			log.Debug("got node index from cmdline")
 		}
 
+		if pubKey := GetPubKeyFromCmdline(cmdline); pubKey != "" {
+			c.pubKey = pubKey
+			log.Debug("got public key from cmdline")
+		}
+
 		if p2p, err := GetPortFromCmdline(cmdline); err == nil {
 			c.p2pPort = uint32(p2p)
 			p2pPortDetected = true
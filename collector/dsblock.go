@@ -0,0 +1,287 @@
+package collector
+
+import (
+	"context"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"strconv"
+	"sync"
+)
+
+var (
+	dsBlockPoWWinnersBuckets = []float64{0, 1, 5, 10, 25, 50, 100, 200, 400, 600, 800, 1000}
+	dsBlockShardPeersBuckets = []float64{0, 10, 50, 100, 200, 300, 400, 500, 600, 800, 1000}
+	dsBlockIntervalBuckets   = []float64{300, 600, 1200, 1800, 2700, 3600, 5400, 7200, 10800, 21600}
+	dsBlockDifficultyTypes   = []string{"shard", "ds"}
+	dsBlockChangeDirections  = []string{"up", "down"}
+)
+
+type dsBlockChange struct {
+	kind      string // shard or ds difficulty, or shards
+	direction string
+}
+
+// DSBlockFollower walks every new DS block from JsonRPC API, with shards formed in the DS epoch
+type DSBlockFollower struct {
+	options   *Options
+	constants *Constants
+	client    *apiclient.Client
+	follower  *blockFollower
+
+	mu         sync.Mutex
+	last       *core.DSBlock // the latest walked block
+	lastShards []int         // peers of shards of the latest walked block with miner info, nil if unknown
+	powWinners *histogramStats
+	shardPeers *histogramStats
+	intervals  *histogramStats
+	changes    map[dsBlockChange]uint64
+
+	height            *prometheus.Desc
+	skipped           *prometheus.Desc
+	blockPoWWinners   *prometheus.Desc
+	blockInterval     *prometheus.Desc
+	blockDifficulty   *prometheus.Desc
+	difficultyChanges *prometheus.Desc
+	blockShards       *prometheus.Desc
+	blockShardPeers   *prometheus.Desc
+	shardCountChanges *prometheus.Desc
+	shardImbalance    *prometheus.Desc
+}
+
+func NewDSBlockFollower(constants *Constants) *DSBlockFollower {
+	commonLabels := constants.CommonLabels()
+	f := &DSBlockFollower{
+		options:    constants.options,
+		constants:  constants,
+		client:     constants.options.GetAPIClient(),
+		follower:   newBlockFollower("ds", constants.options.blockFollowInterval, constants.options.blockFollowMax),
+		powWinners: newHistogramStats(dsBlockPoWWinnersBuckets),
+		shardPeers: newHistogramStats(dsBlockShardPeersBuckets),
+		intervals:  newHistogramStats(dsBlockIntervalBuckets),
+		changes:    make(map[dsBlockChange]uint64),
+		height: prometheus.NewDesc(
+			"ds_block_follower_height", "The latest DS block walked by the block follower",
+			commonLabels, nil,
+		),
+		skipped: prometheus.NewDesc(
+			"ds_block_follower_skipped_total", "DS blocks skipped by the block follower when falling behind",
+			commonLabels, nil,
+		),
+		blockPoWWinners: prometheus.NewDesc(
+			"ds_block_pow_winners", "PoW winners admitted by a DS block",
+			commonLabels, nil,
+		),
+		blockInterval: prometheus.NewDesc(
+			"ds_block_interval_seconds", "Time between consecutive DS blocks by their timestamps",
+			commonLabels, nil,
+		),
+		blockDifficulty: prometheus.NewDesc(
+			"ds_block_difficulty", "Difficulty of the latest walked DS block",
+			append([]string{"difficulty"}, commonLabels...), nil,
+		),
+		difficultyChanges: prometheus.NewDesc(
+			"ds_block_difficulty_changes_total", "Difficulty changes from the previous DS block",
+			append([]string{"difficulty", "direction"}, commonLabels...), nil,
+		),
+		blockShards: prometheus.NewDesc(
+			"ds_block_shards", "Shards formed in the latest walked DS epoch",
+			commonLabels, nil,
+		),
+		blockShardPeers: prometheus.NewDesc(
+			"ds_block_shard_peers", "Peers of a shard formed in a DS epoch",
+			commonLabels, nil,
+		),
+		shardCountChanges: prometheus.NewDesc(
+			"ds_block_shard_count_changes_total", "Changes of the number of shards from the previous DS epoch",
+			append([]string{"direction"}, commonLabels...), nil,
+		),
+		shardImbalance: prometheus.NewDesc(
+			"shard_imbalance", "Difference between the largest and the smallest shard divided by the mean shard size, of the latest walked DS epoch",
+			commonLabels, nil,
+		),
+	}
+	f.follower.latest = f.latest
+	f.follower.walk = f.walk
+	return f
+}
+
+// Start follows DS blocks until Stop is called, it blocks
+func (f *DSBlockFollower) Start() {
+	if f.client == nil {
+		log.Info("ds block follower disabled, API endpoint not set")
+		return
+	}
+	f.follower.start(func() bool {
+		nt := f.constants.NodeType()
+		return nt != UnknownNodeType && !IsGeneralLookup(nt)
+	})
+}
+
+func (f *DSBlockFollower) Stop() {
+	f.follower.close()
+}
+
+func (f *DSBlockFollower) latest(ctx context.Context) (uint64, error) {
+	block, err := f.client.GetLatestDsBlockContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(block.Header.BlockNum, 10, 64)
+}
+
+func (f *DSBlockFollower) walk(ctx context.Context, from, to uint64) uint64 {
+	var requests []*jsonrpc.Request
+	for n := from; n <= to; n++ {
+		requests = append(requests, apiclient.NewGetDsBlockReq(n), apiclient.NewGetMinerInfoReq(n))
+	}
+	batch, err := f.client.CallBatchContext(ctx, requests...)
+	if err != nil {
+		logRPCError(err, "fail to get ds blocks")
+		return from
+	}
+	for i := 0; i < len(requests); i += 2 {
+		n := from + uint64(i/2)
+		block, err := batchDSBlock(batch, requests[i])
+		if err != nil {
+			log.WithError(err).WithField("block", n).Error("fail to get ds block")
+			return n
+		}
+		var shards []int
+		var minerInfo core.MinerInfo
+		if err := batchObject(batch, requests[i+1], &minerInfo); err != nil {
+			// miner info may be pruned or unsupported by the node, shards are unknown for the epoch
+			log.WithError(err).WithField("block", n).Debug("fail to get miner info")
+		} else {
+			shards = make([]int, 0, len(minerInfo.Shards))
+			for _, shard := range minerInfo.Shards {
+				shards = append(shards, shard.Size)
+			}
+		}
+		f.observe(block, shards)
+	}
+	return to + 1
+}
+
+// observe records block with peers of shards formed in its DS epoch, shards is nil if unknown
+func (f *DSBlockFollower) observe(block *core.DSBlock, shards []int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.powWinners.observe(dsBlockPoWWinnersBuckets, float64(len(block.Header.PoWWinners)))
+	if f.last != nil {
+		f.countChange("shard", f.last.Header.Difficulty, block.Header.Difficulty)
+		f.countChange("ds", f.last.Header.DifficultyDS, block.Header.DifficultyDS)
+		if interval, ok := dsBlockInterval(f.last, block); ok {
+			f.intervals.observe(dsBlockIntervalBuckets, interval)
+		}
+	}
+	f.last = block
+
+	if shards == nil {
+		return
+	}
+	for _, peers := range shards {
+		f.shardPeers.observe(dsBlockShardPeersBuckets, float64(peers))
+	}
+	if f.lastShards != nil {
+		f.countChange("shards", len(f.lastShards), len(shards))
+	}
+	f.lastShards = shards
+}
+
+// dsBlockInterval returns seconds from prev to block by their timestamps, false if they are not consecutive or timestamps are unknown
+func dsBlockInterval(prev, block *core.DSBlock) (float64, bool) {
+	prevNum, err1 := strconv.ParseUint(prev.Header.BlockNum, 10, 64)
+	num, err2 := strconv.ParseUint(block.Header.BlockNum, 10, 64)
+	if err1 != nil || err2 != nil || num != prevNum+1 {
+		return 0, false
+	}
+	prevTimestamp, err1 := strconv.ParseUint(prev.Header.Timestamp, 10, 64)
+	timestamp, err2 := strconv.ParseUint(block.Header.Timestamp, 10, 64)
+	if err1 != nil || err2 != nil || timestamp < prevTimestamp {
+		return 0, false
+	}
+	return float64(timestamp-prevTimestamp) / 1e6, true
+}
+
+// countChange counts change of kind from prev to current, f.mu should be held
+func (f *DSBlockFollower) countChange(kind string, prev, current int) {
+	switch {
+	case current > prev:
+		f.changes[dsBlockChange{kind: kind, direction: "up"}]++
+	case current < prev:
+		f.changes[dsBlockChange{kind: kind, direction: "down"}]++
+	}
+}
+
+// ShardImbalance returns (max - min) / mean of peers of shards, 0 if no shards
+func ShardImbalance(shards []int) float64 {
+	if len(shards) == 0 {
+		return 0
+	}
+	min, max, sum := shards[0], shards[0], 0
+	for _, peers := range shards {
+		if peers < min {
+			min = peers
+		}
+		if peers > max {
+			max = peers
+		}
+		sum += peers
+	}
+	if sum == 0 {
+		return 0
+	}
+	return float64(max-min) / (float64(sum) / float64(len(shards)))
+}
+
+func (f *DSBlockFollower) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.height
+	ch <- f.skipped
+	ch <- f.blockPoWWinners
+	ch <- f.blockInterval
+	ch <- f.blockDifficulty
+	ch <- f.difficultyChanges
+	ch <- f.blockShards
+	ch <- f.blockShardPeers
+	ch <- f.shardCountChanges
+	ch <- f.shardImbalance
+}
+
+func (f *DSBlockFollower) Collect(ch chan<- prometheus.Metric) {
+	labels := f.constants.CommonLabelValues()
+	height, ok := f.follower.height()
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(f.height, prometheus.GaugeValue, float64(height), labels...)
+	ch <- prometheus.MustNewConstMetric(f.skipped, prometheus.CounterValue, float64(f.follower.skippedBlocks()), labels...)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch <- f.powWinners.metric(f.blockPoWWinners, dsBlockPoWWinnersBuckets, labels...)
+	ch <- f.intervals.metric(f.blockInterval, dsBlockIntervalBuckets, labels...)
+	ch <- prometheus.MustNewConstMetric(f.blockDifficulty, prometheus.GaugeValue, float64(f.last.Header.Difficulty),
+		append([]string{"shard"}, labels...)...)
+	ch <- prometheus.MustNewConstMetric(f.blockDifficulty, prometheus.GaugeValue, float64(f.last.Header.DifficultyDS),
+		append([]string{"ds"}, labels...)...)
+	for _, kind := range dsBlockDifficultyTypes {
+		for _, direction := range dsBlockChangeDirections {
+			ch <- prometheus.MustNewConstMetric(f.difficultyChanges, prometheus.CounterValue,
+				float64(f.changes[dsBlockChange{kind: kind, direction: direction}]),
+				append([]string{kind, direction}, labels...)...)
+		}
+	}
+	if f.lastShards == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(f.blockShards, prometheus.GaugeValue, float64(len(f.lastShards)), labels...)
+	ch <- f.shardPeers.metric(f.blockShardPeers, dsBlockShardPeersBuckets, labels...)
+	for _, direction := range dsBlockChangeDirections {
+		ch <- prometheus.MustNewConstMetric(f.shardCountChanges, prometheus.CounterValue,
+			float64(f.changes[dsBlockChange{kind: "shards", direction: direction}]),
+			append([]string{direction}, labels...)...)
+	}
+	ch <- prometheus.MustNewConstMetric(f.shardImbalance, prometheus.GaugeValue, ShardImbalance(f.lastShards), labels...)
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testDSBlock struct {
+	winners      int
+	difficulty   int
+	difficultyDS int
+	shards       []int // nil if miner info not available
+}
+
+var testDSBlocks = map[uint64]testDSBlock{
+	20: {winners: 10, difficulty: 5, difficultyDS: 10, shards: []int{600, 600}},
+	21: {winners: 30, difficulty: 6, difficultyDS: 10, shards: []int{600, 600, 300}},
+	22: {winners: 5, difficulty: 4, difficultyDS: 11},
+}
+
+func TestShardImbalance(t *testing.T) {
+	assert := asserting.New(t)
+	assert.Equal(0.0, ShardImbalance(nil))
+	assert.Equal(0.0, ShardImbalance([]int{600, 600}))
+	assert.Equal(0.6, ShardImbalance([]int{600, 600, 300}))
+}
+
+func TestDSBlockFollower(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	blockNum := func(params json.RawMessage) uint64 {
+		var nums []string
+		_ = json.Unmarshal(params, &nums)
+		n, _ := strconv.ParseUint(nums[0], 10, 64)
+		return n
+	}
+	server.SetResults("GetLatestDsBlock",
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "20"}},
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "22"}},
+	)
+	server.SetHandler("GetDsBlock", func(call int, params json.RawMessage) (interface{}, error) {
+		n := blockNum(params)
+		block := testDSBlocks[n]
+		return map[string]interface{}{"header": map[string]interface{}{
+			"BlockNum":     strconv.FormatUint(n, 10),
+			"Timestamp":    strconv.FormatUint(testTxBlockTimestamp+(n-20)*1800e6, 10),
+			"Difficulty":   block.difficulty,
+			"DifficultyDS": block.difficultyDS,
+			"PoWWinners":   make([]string, block.winners),
+		}}, nil
+	})
+	server.SetHandler("GetMinerInfo", func(call int, params json.RawMessage) (interface{}, error) {
+		block := testDSBlocks[blockNum(params)]
+		if block.shards == nil {
+			return nil, jsonrpc.RPCError{Code: -5, Message: "No data"}
+		}
+		var shards []map[string]interface{}
+		for _, size := range block.shards {
+			shards = append(shards, map[string]interface{}{"size": size, "nodes": []string{}})
+		}
+		return map[string]interface{}{"dscommittee": []string{}, "shards": shards}, nil
+	})
+
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	f := NewDSBlockFollower(constants)
+	f.follower.pollOnce()
+	f.follower.pollOnce()
+
+	f.mu.Lock()
+	assert.Equal(uint64(3), f.powWinners.count)
+	assert.Equal(45.0, f.powWinners.sum)
+	assert.Equal(uint64(5), f.shardPeers.count)
+	assert.Equal(uint64(2), f.intervals.count)
+	assert.Equal(3600.0, f.intervals.sum)
+	f.mu.Unlock()
+
+	l := labelText(constants)
+	expected := fmt.Sprintf(`
+# HELP ds_block_follower_height The latest DS block walked by the block follower
+# TYPE ds_block_follower_height gauge
+ds_block_follower_height%s 22
+# HELP ds_block_difficulty Difficulty of the latest walked DS block
+# TYPE ds_block_difficulty gauge
+ds_block_difficulty%s 11
+ds_block_difficulty%s 4
+# HELP ds_block_difficulty_changes_total Difficulty changes from the previous DS block
+# TYPE ds_block_difficulty_changes_total counter
+ds_block_difficulty_changes_total%s 0
+ds_block_difficulty_changes_total%s 1
+ds_block_difficulty_changes_total%s 1
+ds_block_difficulty_changes_total%s 1
+# HELP ds_block_shards Shards formed in the latest walked DS epoch
+# TYPE ds_block_shards gauge
+ds_block_shards%s 3
+# HELP ds_block_shard_count_changes_total Changes of the number of shards from the previous DS epoch
+# TYPE ds_block_shard_count_changes_total counter
+ds_block_shard_count_changes_total%s 0
+ds_block_shard_count_changes_total%s 1
+# HELP shard_imbalance Difference between the largest and the smallest shard divided by the mean shard size, of the latest walked DS epoch
+# TYPE shard_imbalance gauge
+shard_imbalance%s 0.6
+`, l,
+		labelText(constants, "difficulty", "ds"), labelText(constants, "difficulty", "shard"),
+		labelText(constants, "direction", "down", "difficulty", "ds"),
+		labelText(constants, "direction", "down", "difficulty", "shard"),
+		labelText(constants, "direction", "up", "difficulty", "ds"),
+		labelText(constants, "direction", "up", "difficulty", "shard"),
+		l,
+		labelText(constants, "direction", "down"), labelText(constants, "direction", "up"),
+		l,
+	)
+	assert.NoError(testutil.CollectAndCompare(f, strings.NewReader(expected),
+		"ds_block_follower_height", "ds_block_difficulty", "ds_block_difficulty_changes_total",
+		"ds_block_shards", "ds_block_shard_count_changes_total", "shard_imbalance"))
+}
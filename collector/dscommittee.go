@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"encoding/xml"
+	"github.com/pkg/errors"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"os"
+	"sync"
+)
+
+// ReadDSGuards returns public keys of DS guards listed in constants.xml of zilliqa
+func ReadDSGuards(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to open zilliqa constants")
+	}
+	defer f.Close()
+	var constants struct {
+		DSGuards []string `xml:"ds_guard>DSPUBKEY"`
+	}
+	if err := xml.NewDecoder(f).Decode(&constants); err != nil {
+		return nil, errors.Wrap(err, "fail to parse zilliqa constants")
+	}
+	return constants.DSGuards, nil
+}
+
+// dsCommitteeTracker counts membership changes of DS committee between DS epochs
+type dsCommitteeTracker struct {
+	mu      sync.Mutex
+	epoch   int64
+	members map[string]bool
+
+	joined     uint64 // members joined the committee
+	left       uint64 // members left the committee
+	nodeJoined uint64 // times this node joined the committee
+	nodeLeft   uint64 // times this node left the committee
+}
+
+// update compares committee with the one of last DS epoch, the first call only records it
+func (t *dsCommitteeTracker) update(dsEpoch int64, committee adminclient.DSCommittee, self string) {
+	members := make(map[string]bool, len(committee))
+	for _, member := range committee {
+		members[adminclient.NormalizePubKey(member)] = true
+	}
+	self = adminclient.NormalizePubKey(self)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.members != nil && dsEpoch != t.epoch {
+		for member := range members {
+			if !t.members[member] {
+				t.joined++
+			}
+		}
+		for member := range t.members {
+			if !members[member] {
+				t.left++
+			}
+		}
+		if self != "" {
+			if members[self] && !t.members[self] {
+				t.nodeJoined++
+			} else if !members[self] && t.members[self] {
+				t.nodeLeft++
+			}
+		}
+	}
+	t.epoch = dsEpoch
+	t.members = members
+}
+
+func (t *dsCommitteeTracker) changes() (joined, left, nodeJoined, nodeLeft uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.joined, t.left, t.nodeJoined, t.nodeLeft
+}
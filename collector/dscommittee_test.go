@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testZilliqaConstants = `<?xml version="1.0" encoding="utf-8"?>
+<node>
+  <general>
+    <GUARD_MODE>true</GUARD_MODE>
+  </general>
+  <ds_guard>
+    <DSPUBKEY>02AAAA</DSPUBKEY>
+    <DSPUBKEY>02BBBB</DSPUBKEY>
+  </ds_guard>
+  <shard_guard>
+    <SHARDPUBKEY>02CCCC</SHARDPUBKEY>
+  </shard_guard>
+</node>
+`
+
+func TestDSCommitteeTracker(t *testing.T) {
+	assert := asserting.New(t)
+	var tracker dsCommitteeTracker
+	tracker.update(10, adminclient.DSCommittee{"0x02aaaa", "0x02dddd"}, "02DDDD")
+	// same epoch is not compared
+	tracker.update(10, adminclient.DSCommittee{"0x02aaaa"}, "02DDDD")
+	tracker.update(11, adminclient.DSCommittee{"0x02aaaa", "0x02eeee", "0x02ffff"}, "02DDDD")
+	joined, left, nodeJoined, nodeLeft := tracker.changes()
+	assert.Equal(uint64(2), joined)
+	assert.Equal(uint64(0), left)
+	assert.Equal(uint64(0), nodeJoined)
+	assert.Equal(uint64(0), nodeLeft)
+
+	tracker.update(12, adminclient.DSCommittee{"0x02aaaa", "0x02dddd"}, "02DDDD")
+	joined, left, nodeJoined, nodeLeft = tracker.changes()
+	assert.Equal(uint64(3), joined)
+	assert.Equal(uint64(2), left)
+	assert.Equal(uint64(1), nodeJoined)
+	assert.Equal(uint64(0), nodeLeft)
+}
+
+func TestAdminCollectorDSCommittee(t *testing.T) {
+	assert := asserting.New(t)
+	f, err := ioutil.TempFile("", "constants.xml")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString(testZilliqaConstants)
+	_ = f.Close()
+	guards, err := ReadDSGuards(f.Name())
+	assert.NoError(err)
+	assert.Equal([]string{"02AAAA", "02BBBB"}, guards)
+
+	admin := jsonrpctest.NewTCPServer()
+	defer admin.Close()
+	admin.SetResult("GetNodeType", "DS Node")
+	// the first answers are taken by probing admin methods
+	admin.SetResults("GetCurrentDSEpoch", "20", "20", "21")
+	admin.SetResults("GetDSCommittee",
+		[]string{"0x02AAAA", "0x02BBBB", "0x02DDDD", "0x02EEEE"},
+		[]string{"0x02AAAA", "0x02BBBB", "0x02DDDD", "0x02EEEE"},
+		[]string{"0x02AAAA", "0x02BBBB", "0x02FFFF", "0x02DDDD"},
+	)
+	api := jsonrpctest.NewHTTPServer()
+	defer api.Close()
+	api.SetResult("GetLatestDsBlock", map[string]interface{}{
+		"header": map[string]interface{}{"BlockNum": "21", "LeaderPubKey": "0x02DDDD"},
+	})
+
+	options := testOptions(t, "--admin", admin.Addr(), "--api", api.URL, "--pubkey", "02dddd", "--zilliqa-constants", f.Name())
+	constants := testConstants(options, DSGuard)
+	c := NewAdminCollector(constants)
+	// the first scrape records committee of DS epoch 20
+	testutil.CollectAndCount(c)
+
+	l := labelText(constants)
+	expected := fmt.Sprintf(`
+# HELP ds_committee_size Members of DS committee
+# TYPE ds_committee_size gauge
+ds_committee_size%s 4
+# HELP ds_committee_position Position of current node in DS committee, -1 if not a member
+# TYPE ds_committee_position gauge
+ds_committee_position%s 3
+# HELP ds_committee_is_ds_block_leader Whether current node led the consensus of the latest DS block, the current DS leader may differ after view changes
+# TYPE ds_committee_is_ds_block_leader gauge
+ds_committee_is_ds_block_leader%s 1
+# HELP ds_committee_guards DS guards in DS committee
+# TYPE ds_committee_guards gauge
+ds_committee_guards%s 2
+# HELP ds_committee_changes_total Members joined or left DS committee across DS epochs
+# TYPE ds_committee_changes_total counter
+ds_committee_changes_total%s 1
+ds_committee_changes_total%s 1
+`, l, l, l, l, labelText(constants, "change", "joined"), labelText(constants, "change", "left"))
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"ds_committee_size", "ds_committee_position", "ds_committee_is_ds_block_leader", "ds_committee_guards", "ds_committee_changes_total"))
+}
@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// blockFollower polls the latest block number every interval and walks every block since the last one it saw,
+// at most maxBlocks a poll, the older ones are skipped when falling behind
+type blockFollower struct {
+	kind      string // tx or ds, for logs
+	interval  time.Duration
+	maxBlocks uint64
+	// latest returns number of the latest block
+	latest func(ctx context.Context) (uint64, error)
+	// walk processes blocks in order from from to to, it returns the next block to walk,
+	// which is from if the first block failed
+	walk func(ctx context.Context, from, to uint64) uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	started bool   // the latest block is known
+	walked  bool   // any block walked
+	next    uint64 // next block to walk
+	skipped uint64
+}
+
+func newBlockFollower(kind string, interval time.Duration, maxBlocks uint64) *blockFollower {
+	if maxBlocks == 0 {
+		maxBlocks = 1
+	}
+	return &blockFollower{
+		kind:      kind,
+		interval:  interval,
+		maxBlocks: maxBlocks,
+		stop:      make(chan struct{}),
+	}
+}
+
+// start polls every interval until stop is called, it blocks. skip reports whether to skip a poll
+func (f *blockFollower) start(skip func() bool) {
+	entry := log.WithField("kind", f.kind)
+	if f.interval <= 0 {
+		entry.Info("block follower disabled")
+		return
+	}
+	entry.WithField("interval", f.interval).Info("start following blocks")
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			entry.Debug("stop following blocks")
+			return
+		case <-ticker.C:
+			if skip() {
+				continue
+			}
+			f.pollOnce()
+		}
+	}
+}
+
+func (f *blockFollower) close() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+func (f *blockFollower) pollOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), f.interval)
+	defer cancel()
+	entry := log.WithField("kind", f.kind)
+	latest, err := f.latest(ctx)
+	if err != nil {
+		logRPCError(err, "fail to get latest "+f.kind+" block")
+		return
+	}
+
+	f.mu.Lock()
+	if !f.started {
+		// follow from the latest block, history is not walked
+		f.started, f.next = true, latest
+	}
+	if latest+1 < f.next {
+		entry.WithField("latest", latest).WithField("next", f.next).Warn("latest block went backwards, follow it")
+		f.next = latest
+	}
+	if latest+1 == f.next {
+		f.mu.Unlock()
+		return
+	}
+	from := f.next
+	if latest-from+1 > f.maxBlocks {
+		skip := latest - from + 1 - f.maxBlocks
+		entry.WithField("skipped", skip).Warn("falling behind, skip blocks")
+		f.skipped += skip
+		from += skip
+	}
+	f.mu.Unlock()
+
+	next := f.walk(ctx, from, latest)
+	entry.WithField("from", from).WithField("to", latest).WithField("next", next).Debug("walked blocks")
+	f.mu.Lock()
+	if next > from {
+		f.walked = true
+	}
+	f.next = next
+	f.mu.Unlock()
+}
+
+// height returns the last walked block, false if none
+func (f *blockFollower) height() (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.walked {
+		return 0, false
+	}
+	return f.next - 1, true
+}
+
+func (f *blockFollower) skippedBlocks() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.skipped
+}
@@ -0,0 +1,33 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sort"
+)
+
+// histogramStats accumulates observations, to be exported as const histogram with dynamic common labels
+type histogramStats struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // cumulative, same order as bounds
+}
+
+func newHistogramStats(bounds []float64) *histogramStats {
+	return &histogramStats{buckets: make([]uint64, len(bounds))}
+}
+
+func (h *histogramStats) observe(bounds []float64, value float64) {
+	h.count++
+	h.sum += value
+	for i := sort.SearchFloat64s(bounds, value); i < len(bounds); i++ {
+		h.buckets[i]++
+	}
+}
+
+func (h *histogramStats) metric(desc *prometheus.Desc, bounds []float64, labelValues ...string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(bounds))
+	for i, bound := range bounds {
+		buckets[bound] = h.buckets[i]
+	}
+	return prometheus.MustNewConstHistogram(desc, h.count, h.sum, buckets, labelValues...)
+}
@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"sync"
+	"time"
+)
+
+var nodeStateDurationBuckets = []float64{.5, 1, 2, 5, 10, 20, 30, 60, 120, 300, 600}
+
+type nodeStateTransition struct {
+	from adminclient.NodeState
+	to   adminclient.NodeState
+}
+
+// NodeStateSampler polls GetNodeState of admin API in background,
+// to count state transitions and time spent in every state, which a scrape is too coarse to see
+type NodeStateSampler struct {
+	options   *Options
+	constants *Constants
+	client    *adminclient.Client
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	mu          sync.Mutex
+	current     adminclient.NodeState
+	known       bool // current is valid, false before first sample or after a failed one
+	since       time.Time
+	transitions map[nodeStateTransition]uint64
+	durations   map[adminclient.NodeState]*histogramStats
+
+	stateTransitions *prometheus.Desc
+	stateDuration    *prometheus.Desc
+}
+
+func NewNodeStateSampler(constants *Constants) *NodeStateSampler {
+	commonLabels := constants.CommonLabels()
+	return &NodeStateSampler{
+		options:     constants.options,
+		constants:   constants,
+		client:      constants.options.GetAdminClient(),
+		interval:    constants.options.nodeStateInterval,
+		stop:        make(chan struct{}),
+		transitions: make(map[nodeStateTransition]uint64),
+		durations:   make(map[adminclient.NodeState]*histogramStats),
+		stateTransitions: prometheus.NewDesc(
+			"node_state_transitions_total", "Node state transitions seen by the sampler",
+			append([]string{"from", "to"}, commonLabels...), nil,
+		),
+		stateDuration: prometheus.NewDesc(
+			"node_state_duration_seconds", "Time spent in a node state, observed when the node leaves it",
+			append([]string{"state"}, commonLabels...), nil,
+		),
+	}
+}
+
+// Start samples node state every interval until Stop is called, it blocks
+func (s *NodeStateSampler) Start() {
+	if s.interval <= 0 || s.client == nil {
+		log.Info("node state sampler disabled")
+		return
+	}
+	log.WithField("interval", s.interval).Info("start sampling node state")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			log.Debug("stop sampling node state")
+			return
+		case <-ticker.C:
+			if !servesNodeState(s.constants.NodeType()) {
+				continue
+			}
+			s.sampleOnce()
+		}
+	}
+}
+
+// servesNodeState reports whether nodes of type nt answer GetNodeState,
+// lookups do not, nor are nodes of unknown type called
+func servesNodeState(nt NodeType) bool {
+	return nt == Normal || nt == DSGuard
+}
+
+func (s *NodeStateSampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *NodeStateSampler) sampleOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+	defer cancel()
+	state, err := s.client.GetNodeStateContext(ctx)
+	if err != nil {
+		log.WithError(err).Debug("fail to sample node state")
+		s.reset()
+		return
+	}
+	s.sample(time.Now(), state)
+}
+
+// reset forgets the current state, the time a node is unreachable is not counted in any state
+func (s *NodeStateSampler) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known = false
+}
+
+func (s *NodeStateSampler) sample(now time.Time, state adminclient.NodeState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known {
+		s.current, s.since, s.known = state, now, true
+		return
+	}
+	if state == s.current {
+		return
+	}
+	stats, ok := s.durations[s.current]
+	if !ok {
+		stats = newHistogramStats(nodeStateDurationBuckets)
+		s.durations[s.current] = stats
+	}
+	stats.observe(nodeStateDurationBuckets, now.Sub(s.since).Seconds())
+	s.transitions[nodeStateTransition{from: s.current, to: state}]++
+	log.WithField("from", s.current.String()).WithField("to", state.String()).Debug("node state changed")
+	s.current, s.since = state, now
+}
+
+func (s *NodeStateSampler) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.stateTransitions
+	ch <- s.stateDuration
+}
+
+func (s *NodeStateSampler) Collect(ch chan<- prometheus.Metric) {
+	labels := s.constants.CommonLabelValues()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for transition, count := range s.transitions {
+		ch <- prometheus.MustNewConstMetric(s.stateTransitions, prometheus.CounterValue, float64(count),
+			append([]string{transition.from.String(), transition.to.String()}, labels...)...)
+	}
+	for state, stats := range s.durations {
+		ch <- stats.metric(s.stateDuration, nodeStateDurationBuckets, append([]string{state.String()}, labels...)...)
+	}
+}
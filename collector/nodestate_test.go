@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNodeStateSampler(t *testing.T) {
+	assert := asserting.New(t)
+	constants := testConstants(testOptions(t), DSGuard)
+	s := NewNodeStateSampler(constants)
+
+	start := time.Unix(1600000000, 0)
+	s.sample(start, adminclient.POW_SUBMISSION)
+	s.sample(start.Add(time.Second), adminclient.POW_SUBMISSION)
+	s.sample(start.Add(3*time.Second), adminclient.DSBLOCK_CONSENSUS)
+	// unreachable node is not counted in any state
+	s.reset()
+	s.sample(start.Add(100*time.Second), adminclient.DSBLOCK_CONSENSUS)
+	s.sample(start.Add(104*time.Second), adminclient.POW_SUBMISSION)
+
+	expected := fmt.Sprintf(`
+# HELP node_state_transitions_total Node state transitions seen by the sampler
+# TYPE node_state_transitions_total counter
+node_state_transitions_total%s 1
+node_state_transitions_total%s 1
+`,
+		labelText(constants, "from", "DSBLOCK_CONSENSUS", "to", "POW_SUBMISSION"),
+		labelText(constants, "from", "POW_SUBMISSION", "to", "DSBLOCK_CONSENSUS"),
+	)
+	assert.NoError(testutil.CollectAndCompare(s, strings.NewReader(expected), "node_state_transitions_total"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Equal(uint64(1), s.durations[adminclient.POW_SUBMISSION].count)
+	assert.Equal(3.0, s.durations[adminclient.POW_SUBMISSION].sum)
+	assert.Equal(4.0, s.durations[adminclient.DSBLOCK_CONSENSUS].sum)
+}
+
+func TestNodeStateSamplerPoll(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResults("GetNodeState", "MICROBLOCK_SUBMISSION", "FINALBLOCK_CONSENSUS")
+
+	constants := testConstants(testOptions(t, "--admin", server.Addr(), "--node-state-interval", "10ms"), Normal)
+	s := NewNodeStateSampler(constants)
+	go s.Start()
+	defer s.Stop()
+	assert.Eventually(func() bool {
+		return testutil.CollectAndCount(s, "node_state_transitions_total") == 1
+	}, time.Second, 10*time.Millisecond)
+	// polled through the breaker of the admin endpoint
+	assert.Same(constants.options.GetAdminClient().Breaker(), s.client.Breaker())
+}
+
+func TestNodeStateSamplerSkipped(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("GetNodeState", "MICROBLOCK_SUBMISSION")
+
+	options := testOptions(t, "--admin", server.Addr(), "--node-state-interval", "10ms")
+	for _, nt := range []NodeType{Lookup, SeedPub, UnknownNodeType} {
+		s := NewNodeStateSampler(testConstants(options, nt))
+		go s.Start()
+		time.Sleep(50 * time.Millisecond)
+		s.Stop()
+	}
+	assert.Equal(0, server.Calls("GetNodeState"))
+
+	// disabled by default
+	assert.Zero(testOptions(t).nodeStateInterval)
+}
+
+func TestAdminCollectorNodeState(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewTCPServer()
+	defer server.Close()
+	server.SetResult("GetNodeType", "Shard Node of shard 1")
+	server.SetResult("GetNodeState", "FINALBLOCK_CONSENSUS")
+
+	constants := testConstants(testOptions(t, "--admin", server.Addr()), Normal)
+	c := NewAdminCollector(constants)
+	expected := `
+# HELP node_state Node state, 1 for the current state
+# TYPE node_state gauge
+`
+	for _, state := range adminclient.NodeStates {
+		var value int
+		if state == adminclient.FINALBLOCK_CONSENSUS {
+			value = 1
+		}
+		expected += fmt.Sprintf("node_state%s %d\n", labelText(constants, "state", state.String()), value)
+	}
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "node_state"))
+}
@@ -3,14 +3,17 @@ package collector
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/Zilliqa/gozilliqa-sdk/provider"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
 	"github.com/zilliqa/zilliqa-exporter/utils"
+	"net/http"
 	"net/url"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,7 +24,8 @@ const (
 )
 
 type Options struct {
-	IsMainNet bool
+	IsMainNet   bool
+	EnableProbe bool
 
 	NotCollectAPI         bool
 	NotCollectAdmin       bool
@@ -29,6 +33,7 @@ type Options struct {
 	NotCollectProcessInfo bool
 
 	zilliqaBin string
+	configFile string
 
 	p2pPort           uint32
 	apiEndpoint       string
@@ -37,23 +42,173 @@ type Options struct {
 
 	rpcTimeout time.Duration
 
-	nodeType string
+	pollInterval        time.Duration
+	scrapeTimeoutOffset time.Duration
+
+	nodeStateInterval time.Duration
+
+	blockFollowInterval time.Duration
+	blockFollowMax      uint64
+
+	accountInterval time.Duration
+
+	blacklistExclusionFile      string
+	blacklistExclusionStateFile string
+	blacklistExclusionInterval  time.Duration
+
+	rpcRetries         int
+	rpcRetryBackoff    time.Duration
+	rpcRetryMaxBackoff time.Duration
+	rpcBreakerFailures int
+	rpcBreakerCooldown time.Duration
+
+	apiHeaders   []string
+	apiBasicAuth string
+	apiFallbacks []string
+
+	referenceEndpoints []string
+
+	adminPoolMaxIdle     int
+	adminPoolIdleTimeout time.Duration
+	adminMaxResponseSize int64
+
+	adminMethodProbeInterval time.Duration
+
+	adminTLS                   bool
+	adminTLSCA                 string
+	adminTLSCert               string
+	adminTLSKey                string
+	adminTLSServerName         string
+	adminTLSInsecureSkipVerify bool
+
+	nodeType         string
+	pubKey           string
+	zilliqaConstants string
+
+	rpcObserver jsonrpc.Observer
+
+	// shared by copies of options, nil to create new ones for every client
+	endpoints *endpoints
 }
 
+// endpoints keeps the circuit breaker and the admin connection pool of every endpoint, created on first use,
+// so that every client of an endpoint counts failures on the same breaker and reuses the same connections
+type endpoints struct {
+	mu       sync.Mutex
+	breakers map[string]*jsonrpc.CircuitBreaker
+	adminTCP map[string]*jsonrpc.TCPClient
+}
+
+func (e *endpoints) breaker(endpoint string, create func() *jsonrpc.CircuitBreaker) *jsonrpc.CircuitBreaker {
+	if e == nil {
+		return create()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.breakers == nil {
+		e.breakers = make(map[string]*jsonrpc.CircuitBreaker)
+	}
+	b, ok := e.breakers[endpoint]
+	if !ok {
+		b = create()
+		e.breakers[endpoint] = b
+	}
+	return b
+}
+
+func (e *endpoints) tcpClient(endpoint string, create func() *jsonrpc.TCPClient) *jsonrpc.TCPClient {
+	if e == nil {
+		return create()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.adminTCP == nil {
+		e.adminTCP = make(map[string]*jsonrpc.TCPClient)
+	}
+	c, ok := e.adminTCP[endpoint]
+	if !ok {
+		c = create()
+		e.adminTCP[endpoint] = c
+	}
+	return c
+}
+
+// BindFlags binds every flag of the exporter to set
 func (c *Options) BindFlags(set *pflag.FlagSet) {
+	c.BindConnectionFlags(set)
+	c.BindServerFlags(set)
+}
+
+// BindConnectionFlags binds flags of the connection to admin api to set, shared by the exporter and admin subcommands
+func (c *Options) BindConnectionFlags(set *pflag.FlagSet) {
 	set.SortFlags = false
-	set.BoolVar(&c.IsMainNet, "mainnet", false, "collect mainnet metrics")
+	c.endpoints = &endpoints{}
+	set.StringVar(&c.adminEndpoint, "admin", "", "zilliqa admin api endpoint, host:port or unix:///path/to/socket")
+	set.DurationVarP(&c.rpcTimeout, "rpc-timeout", "t", 10*time.Second, "timeout of rpc request")
+	set.Int64Var(&c.adminMaxResponseSize, "admin-max-response-size", jsonrpc.DefaultMaxResponseSize, "max bytes of a single admin api response, 0 means no limit")
+	set.BoolVar(&c.adminTLS, "admin-tls", false, "connect to admin api with TLS, implied by other --admin-tls-* options")
+	set.StringVar(&c.adminTLSCA, "admin-tls-ca", "", "CA certificate file to verify admin api server")
+	set.StringVar(&c.adminTLSCert, "admin-tls-cert", "", "client certificate file for admin api mutual TLS")
+	set.StringVar(&c.adminTLSKey, "admin-tls-key", "", "client key file for admin api mutual TLS")
+	set.StringVar(&c.adminTLSServerName, "admin-tls-server-name", "", "server name to verify admin api server certificate")
+	set.BoolVar(&c.adminTLSInsecureSkipVerify, "admin-tls-insecure-skip-verify", false, "do not verify admin api server certificate")
+}
+
+// BindServerFlags binds flags only used by the exporter serving metrics to set
+func (c *Options) BindServerFlags(set *pflag.FlagSet) {
+	set.SortFlags = false
+	set.StringVar(&c.configFile, "config", "", "yaml config file of the exporter")
+	set.BoolVar(&c.IsMainNet, "mainnet", false, "collect mainnet metrics, the state of unstoppable domains contract")
+	set.BoolVar(&c.EnableProbe, "enable-probe", false, "serve /probe for targets of modules in config")
 	set.BoolVar(&c.NotCollectAPI, "not-collect-api", false, "do not collect metrics from JSONRPC API")
 	set.BoolVar(&c.NotCollectAdmin, "not-collect-admin", false, "do not collect metrics from Admin API")
 	set.BoolVar(&c.NotCollectWebsocket, "not-collect-websocket", false, "do not collect metrics from Websocket API")
 	set.BoolVar(&c.NotCollectProcessInfo, "not-collect-process-info", false, "do not collect metrics from Zilliqa Process")
-	set.DurationVarP(&c.rpcTimeout, "rpc-timeout", "t", 10*time.Second, "timeout of rpc request")
+	set.DurationVar(&c.pollInterval, "poll-interval", 0, "interval of refreshing metrics of api, admin, reference and process info collectors in background, served to scrapes from snapshot; 0 to collect on every scrape")
+	set.DurationVar(&c.scrapeTimeoutOffset, "scrape-timeout-offset", 500*time.Millisecond, "time left to respond before the scrape timeout of Prometheus, when collectors calling the node stop")
+	set.DurationVar(&c.nodeStateInterval, "node-state-interval", 0, "interval of sampling node state from admin api of normal and ds guard nodes, e.g. 1s, 0 to disable")
+	set.DurationVar(&c.blockFollowInterval, "block-follow-interval", 10*time.Second, "interval of polling new blocks from jsonrpc api, 0 to disable block followers")
+	set.Uint64Var(&c.blockFollowMax, "block-follow-max", 50, "max blocks walked in a poll, older blocks are skipped when falling behind")
+	set.DurationVar(&c.accountInterval, "account-interval", time.Minute, "interval of polling balances of accounts in config, 0 to disable")
+	set.StringVar(&c.blacklistExclusionFile, "blacklist-exclusion-file", "", "file of ips to keep excluded from blacklist of the node, one per line, reconciler disabled if empty")
+	set.StringVar(&c.blacklistExclusionStateFile, "blacklist-exclusion-state-file", "", "writable file keeping ips excluded by the exporter, so that ips removed from blacklist exclusion file while the exporter is down are removed from the node, not kept if empty")
+	set.DurationVar(&c.blacklistExclusionInterval, "blacklist-exclusion-interval", 30*time.Second, "interval of checking admin api and blacklist exclusion file")
+	set.IntVar(&c.adminPoolMaxIdle, "admin-pool-max-idle", 2, "max idle connections kept to admin api, 0 to disable connection reuse")
+	set.DurationVar(&c.adminPoolIdleTimeout, "admin-pool-idle-timeout", 30*time.Second, "close idle connections to admin api after this duration")
+	set.DurationVar(&c.adminMethodProbeInterval, "admin-method-probe-interval", 10*time.Minute, "interval of probing admin api methods supported by the node, which change when the node is upgraded, 0 to probe only once")
+	set.IntVar(&c.rpcRetries, "rpc-retries", 1, "retries of a failed rpc request within --rpc-timeout")
+	set.DurationVar(&c.rpcRetryBackoff, "rpc-retry-backoff", 200*time.Millisecond, "backoff before the first retry, doubled on every retry")
+	set.DurationVar(&c.rpcRetryMaxBackoff, "rpc-retry-max-backoff", 2*time.Second, "max backoff between retries")
+	set.IntVar(&c.rpcBreakerFailures, "rpc-breaker-failures", 3, "consecutive failures before stop calling an endpoint, 0 to disable circuit breaker")
+	set.DurationVar(&c.rpcBreakerCooldown, "rpc-breaker-cooldown", time.Minute, "time before calling an endpoint again after circuit breaker opened")
 	set.Uint32Var(&c.p2pPort, "p2p-port", 33133, "p2p port of zilliqa node")
 	set.StringVar(&c.apiEndpoint, "api", "", "zilliqa jsonrpc endpoint")
-	set.StringVar(&c.adminEndpoint, "admin", "", "zilliqa admin api endpoint")
+	set.StringArrayVar(&c.apiHeaders, "api-header", nil, `extra http header sent to jsonrpc api, in the form of "Name: value"`)
+	set.StringVar(&c.apiBasicAuth, "api-basic-auth", "", `basic auth of jsonrpc api, in the form of "user:password"`)
+	set.StringSliceVar(&c.apiFallbacks, "api-fallback", nil, "fallback jsonrpc endpoints used when --api fails")
+	set.StringSliceVar(&c.referenceEndpoints, "reference-api", nil, "jsonrpc endpoints of reference lookups to compare epochs of the node with")
 	set.StringVar(&c.websocketEndpoint, "ws", "", "zilliqa websocket api endpoint")
 	set.StringVar(&c.zilliqaBin, "bin", "zilliqa", "the zilliqa executable name or path")
 	set.StringVar(&c.nodeType, "type", "", "zilliqa node type")
+	set.StringVar(&c.pubKey, "pubkey", "", "public key of zilliqa node, detected from cmdline of zilliqa process if empty")
+	set.StringVar(&c.zilliqaConstants, "zilliqa-constants", "", "constants.xml of zilliqa node, the one in working directory of zilliqa process if empty")
+}
+
+// LoadConfig loads config file set by --config, with the contract of unstoppable domains watched if --mainnet
+func (c *Options) LoadConfig() (*Config, error) {
+	config, err := LoadConfig(c.configFile)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsMainNet {
+		for _, contract := range config.Contracts {
+			if contract.Name == UDContract.Name {
+				return config, nil
+			}
+		}
+		config.Contracts = append(config.Contracts, UDContract)
+	}
+	return config, nil
 }
 
 func (c *Options) ZilliqaBinPath() string {
@@ -111,20 +266,147 @@ func (c Options) WebsocketEndpoint() string {
 	return c.websocketEndpoint
 }
 
-func (c Options) GetAPIClient() *provider.Provider {
+func (c Options) RPCTimeout() time.Duration {
+	return c.rpcTimeout
+}
+
+// PollInterval returns interval of refreshing snapshots of collectors, 0 if collected on every scrape
+func (c Options) PollInterval() time.Duration {
+	return c.pollInterval
+}
+
+func (c Options) GetAPIClient() *apiclient.Client {
 	ep := c.APIEndpoint()
 	if ep == "" {
 		return nil
 	}
-	return provider.NewProvider(ep)
+	cli := apiclient.New(ep, c.rpcTimeout, c.APIHTTPOptions())
+	if c.rpcObserver != nil {
+		cli.SetObserver(c.rpcObserver)
+	}
+	cli.SetResilience(c.RetryPolicy(), c.CircuitBreaker(ep))
+	return cli
+}
+
+// GetReferenceClients returns clients of reference lookups, without the headers and auth of --api
+func (c Options) GetReferenceClients() []*apiclient.Client {
+	var clients []*apiclient.Client
+	for _, ep := range c.referenceEndpoints {
+		if !strings.HasPrefix(ep, "http://") && !strings.HasPrefix(ep, "https://") {
+			ep = fmt.Sprintf("http://%s", ep)
+		}
+		cli := apiclient.New(ep, c.rpcTimeout, jsonrpc.HTTPOptions{})
+		if c.rpcObserver != nil {
+			cli.SetObserver(c.rpcObserver)
+		}
+		cli.SetResilience(c.RetryPolicy(), c.CircuitBreaker(ep))
+		clients = append(clients, cli)
+	}
+	return clients
+}
+
+// SetRPCObserver sets the observer of every call made by clients created afterwards
+func (c *Options) SetRPCObserver(observer jsonrpc.Observer) {
+	c.rpcObserver = observer
+}
+
+func (c Options) RetryPolicy() jsonrpc.RetryPolicy {
+	return jsonrpc.RetryPolicy{
+		MaxAttempts:    c.rpcRetries + 1,
+		InitialBackoff: c.rpcRetryBackoff,
+		MaxBackoff:     c.rpcRetryMaxBackoff,
+	}
+}
+
+// CircuitBreaker returns the circuit breaker of endpoint, the same one for every client of the endpoint
+func (c Options) CircuitBreaker(endpoint string) *jsonrpc.CircuitBreaker {
+	return c.endpoints.breaker(endpoint, func() *jsonrpc.CircuitBreaker {
+		return jsonrpc.NewCircuitBreaker(endpoint, c.rpcBreakerFailures, c.rpcBreakerCooldown)
+	})
+}
+
+func (c Options) APIHTTPOptions() jsonrpc.HTTPOptions {
+	opts := jsonrpc.HTTPOptions{Headers: http.Header{}}
+	for _, h := range c.apiHeaders {
+		split := strings.SplitN(h, ":", 2)
+		if len(split) != 2 {
+			log.WithField("header", h).Error("invalid api header, should be in the form of \"Name: value\"")
+			continue
+		}
+		opts.Headers.Add(strings.TrimSpace(split[0]), strings.TrimSpace(split[1]))
+	}
+	if c.apiBasicAuth != "" {
+		split := strings.SplitN(c.apiBasicAuth, ":", 2)
+		opts.Username = split[0]
+		if len(split) == 2 {
+			opts.Password = split[1]
+		}
+	}
+	for _, ep := range c.apiFallbacks {
+		if !strings.HasPrefix(ep, "http://") && !strings.HasPrefix(ep, "https://") {
+			ep = fmt.Sprintf("http://%s", ep)
+		}
+		opts.Fallbacks = append(opts.Fallbacks, ep)
+	}
+	return opts
 }
 
 func (c Options) GetAdminClient() *adminclient.Client {
+	cli := c.GetAdminOperationClient()
+	if cli == nil {
+		return nil
+	}
+	cli.SetResilience(c.RetryPolicy(), c.CircuitBreaker(c.AdminEndpoint()))
+	return cli
+}
+
+// GetAdminOperationClient returns an admin client without retry and circuit breaker,
+// for mutating calls which are not safe to repeat. Admin clients share the connection pool of the endpoint
+func (c Options) GetAdminOperationClient() *adminclient.Client {
 	ep := c.AdminEndpoint()
 	if ep == "" {
 		return nil
 	}
-	return adminclient.New(ep, c.rpcTimeout)
+	opts, err := c.AdminTCPOptions()
+	if err != nil {
+		log.WithError(err).Error("invalid admin api TLS options")
+		return nil
+	}
+	tcp := c.endpoints.tcpClient(ep, func() *jsonrpc.TCPClient {
+		return jsonrpc.NewTCPClientWithOptions(ep, opts)
+	})
+	cli := adminclient.NewWithTCPClient(tcp, c.rpcTimeout)
+	if c.rpcObserver != nil {
+		cli.SetObserver(c.rpcObserver)
+	}
+	return cli
+}
+
+func (c Options) AdminTLSEnabled() bool {
+	return c.adminTLS || c.adminTLSCA != "" || c.adminTLSCert != "" || c.adminTLSKey != "" ||
+		c.adminTLSServerName != "" || c.adminTLSInsecureSkipVerify
+}
+
+func (c Options) AdminTCPOptions() (jsonrpc.TCPOptions, error) {
+	opts := jsonrpc.DefaultTCPOptions()
+	opts.PoolMaxIdle = c.adminPoolMaxIdle
+	opts.PoolIdleTimeout = c.adminPoolIdleTimeout
+	opts.MaxResponseSize = c.adminMaxResponseSize
+	if c.AdminTLSEnabled() {
+		tlsOpts := jsonrpc.TLSOptions{
+			CAFile:             c.adminTLSCA,
+			CertFile:           c.adminTLSCert,
+			KeyFile:            c.adminTLSKey,
+			ServerName:         c.adminTLSServerName,
+			InsecureSkipVerify: c.adminTLSInsecureSkipVerify,
+		}
+		cfg, err := tlsOpts.Config()
+		if err != nil {
+			return opts, err
+		}
+		opts.TLSConfig = cfg
+	}
+	return opts, nil
 }
 
 func (c *Options) MarshalJSON() ([]byte, error) {
@@ -133,17 +415,39 @@ func (c *Options) MarshalJSON() ([]byte, error) {
 
 func (c *Options) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"IsMainnet":             c.IsMainNet,
-		"NotCollectAPI":         c.NotCollectAPI,
-		"NotCollectAdmin":       c.NotCollectAdmin,
-		"NotCollectWebsocket":   c.NotCollectWebsocket,
-		"NotCollectProcessInfo": c.NotCollectProcessInfo,
-		"ZilliqaBinPath":        c.ZilliqaBinPath(),
-		"p2pPort":               c.p2pPort,
-		"ApiEndpoint":           c.APIEndpoint(),
-		"AdminEndpoint":         c.AdminEndpoint(),
-		"WebsocketEndpoint":     c.WebsocketEndpoint(),
-		"RpcTimeout":            c.rpcTimeout.String(),
-		"NodeType":              c.nodeType,
+		"IsMainnet":                   c.IsMainNet,
+		"NotCollectAPI":               c.NotCollectAPI,
+		"NotCollectAdmin":             c.NotCollectAdmin,
+		"NotCollectWebsocket":         c.NotCollectWebsocket,
+		"NotCollectProcessInfo":       c.NotCollectProcessInfo,
+		"ConfigFile":                  c.configFile,
+		"ZilliqaBinPath":              c.ZilliqaBinPath(),
+		"p2pPort":                     c.p2pPort,
+		"ApiEndpoint":                 c.APIEndpoint(),
+		"AdminEndpoint":               c.AdminEndpoint(),
+		"WebsocketEndpoint":           c.WebsocketEndpoint(),
+		"RpcTimeout":                  c.rpcTimeout.String(),
+		"RpcRetries":                  c.rpcRetries,
+		"PollInterval":                c.pollInterval.String(),
+		"ScrapeTimeoutOffset":         c.scrapeTimeoutOffset.String(),
+		"RpcBreakerFailures":          c.rpcBreakerFailures,
+		"RpcBreakerCooldown":          c.rpcBreakerCooldown.String(),
+		"ApiHeaders":                  len(c.apiHeaders),
+		"ApiBasicAuth":                c.apiBasicAuth != "",
+		"ApiFallbacks":                c.apiFallbacks,
+		"ReferenceEndpoints":          c.referenceEndpoints,
+		"AdminPoolMaxIdle":            c.adminPoolMaxIdle,
+		"AdminPoolIdleTimeout":        c.adminPoolIdleTimeout.String(),
+		"AdminMaxResponseSize":        c.adminMaxResponseSize,
+		"AdminTLS":                    c.AdminTLSEnabled(),
+		"NodeType":                    c.nodeType,
+		"NodeStateInterval":           c.nodeStateInterval.String(),
+		"BlockFollowInterval":         c.blockFollowInterval.String(),
+		"BlockFollowMax":              c.blockFollowMax,
+		"AccountInterval":             c.accountInterval.String(),
+		"BlacklistExclusionFile":      c.blacklistExclusionFile,
+		"BlacklistExclusionStateFile": c.blacklistExclusionStateFile,
+		"PubKey":                      c.pubKey,
+		"ZilliqaConstants":            c.zilliqaConstants,
 	}
 }
@@ -23,3 +23,18 @@ func TestCheckEndpoint(t *testing.T) {
 	cli := options.GetAPIClient()
 	assert.NotNil(cli)
 }
+
+func TestSharedEndpoints(t *testing.T) {
+	assert := asserting.New(t)
+	options := testOptions(t, "--api", "127.0.0.1:4201", "--admin", "127.0.0.1:4301")
+
+	assert.Same(options.GetAPIClient().Breaker(), options.GetAPIClient().Breaker())
+	admin, operation := options.GetAdminClient(), options.GetAdminOperationClient()
+	assert.Same(admin.Breaker(), options.GetAdminClient().Breaker())
+	assert.Same(admin.TCPClient(), operation.TCPClient())
+	assert.NotSame(admin.Breaker(), options.GetAPIClient().Breaker())
+
+	// a probe of a target does not share breakers and connections of the node
+	constants := NewProbeHandler(options, nil).targetConstants("127.0.0.1:4301", ProbeModule{Prober: "admin"})
+	assert.NotSame(admin.TCPClient(), constants.options.GetAdminClient().TCPClient())
+}
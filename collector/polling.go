@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// PollingCollector collects metrics of a collector in background every --poll-interval, and serves the snapshot to scrapes,
+// so that scrapes do not call the node, and a slow node does not stall /metrics.
+// Refreshes running at the same time are coalesced into one
+type PollingCollector struct {
+	constants *Constants
+	name      string
+	collector prometheus.Collector
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+	now       func() time.Time
+
+	mu          sync.Mutex
+	metrics     []prometheus.Metric
+	refreshedAt time.Time     // zero before the first refresh
+	refreshing  chan struct{} // closed when the running refresh is done, nil if none running
+
+	age   *prometheus.Desc
+	stale *prometheus.Desc
+}
+
+func NewPollingCollector(constants *Constants, name string, collector prometheus.Collector) *PollingCollector {
+	commonLabels := constants.CommonLabels()
+	return &PollingCollector{
+		constants: constants,
+		name:      name,
+		collector: collector,
+		interval:  constants.options.pollInterval,
+		stop:      make(chan struct{}),
+		now:       time.Now,
+		age: prometheus.NewDesc(
+			"collector_snapshot_age_seconds", "Seconds since the snapshot of the collector served to scrapes was refreshed",
+			append([]string{"collector"}, commonLabels...), nil,
+		),
+		stale: prometheus.NewDesc(
+			"collector_snapshot_stale", "The snapshot of the collector is not refreshed for two poll intervals",
+			append([]string{"collector"}, commonLabels...), nil,
+		),
+	}
+}
+
+// Start refreshes the snapshot every interval until Stop is called, it blocks
+func (p *PollingCollector) Start() {
+	if p.interval <= 0 {
+		return
+	}
+	log.WithField("collector", p.name).WithField("interval", p.interval).Info("start polling collector")
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.refresh()
+		select {
+		case <-p.stop:
+			log.WithField("collector", p.name).Debug("stop polling collector")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PollingCollector) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// refresh collects a new snapshot, or waits for the one being collected
+func (p *PollingCollector) refresh() {
+	p.mu.Lock()
+	if done := p.refreshing; done != nil {
+		p.mu.Unlock()
+		<-done
+		return
+	}
+	done := make(chan struct{})
+	p.refreshing = done
+	p.mu.Unlock()
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		p.collector.Collect(ch)
+		close(ch)
+	}()
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	p.mu.Lock()
+	p.metrics, p.refreshedAt, p.refreshing = metrics, p.now(), nil
+	p.mu.Unlock()
+	close(done)
+}
+
+func (p *PollingCollector) Describe(ch chan<- *prometheus.Desc) {
+	p.collector.Describe(ch)
+	ch <- p.age
+	ch <- p.stale
+}
+
+func (p *PollingCollector) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	refreshed := !p.refreshedAt.IsZero()
+	p.mu.Unlock()
+	if !refreshed {
+		// scraped before the first refresh finished
+		p.refresh()
+	}
+
+	p.mu.Lock()
+	metrics, refreshedAt := p.metrics, p.refreshedAt
+	p.mu.Unlock()
+	for _, m := range metrics {
+		ch <- m
+	}
+	labels := append([]string{p.name}, p.constants.CommonLabelValues()...)
+	age := p.now().Sub(refreshedAt)
+	ch <- prometheus.MustNewConstMetric(p.age, prometheus.GaugeValue, age.Seconds(), labels...)
+	var stale float64
+	if age > 2*p.interval {
+		stale = 1
+	}
+	ch <- prometheus.MustNewConstMetric(p.stale, prometheus.GaugeValue, stale, labels...)
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSlowCollector counts its collects, every collect waits for release
+type testSlowCollector struct {
+	desc    *prometheus.Desc
+	calls   int32
+	release chan struct{}
+}
+
+func (c *testSlowCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *testSlowCollector) Collect(ch chan<- prometheus.Metric) {
+	calls := atomic.AddInt32(&c.calls, 1)
+	<-c.release
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(calls))
+}
+
+func TestPollingCollector(t *testing.T) {
+	assert := asserting.New(t)
+	constants := testConstants(testOptions(t, "--poll-interval", "10s"), Lookup)
+	slow := &testSlowCollector{desc: prometheus.NewDesc("test_collects", "Collects of test collector", nil, nil), release: make(chan struct{})}
+	p := NewPollingCollector(constants, "test", slow)
+	now := time.Unix(1600000000, 0)
+	p.now = func() time.Time { return now }
+
+	// scrapes before the first refresh wait for the same refresh
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(3, testutil.CollectAndCount(p))
+		}()
+	}
+	assert.Eventually(func() bool { return atomic.LoadInt32(&slow.calls) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(slow.release)
+	wg.Wait()
+	assert.Equal(int32(1), atomic.LoadInt32(&slow.calls))
+
+	l := labelText(constants, "collector", "test")
+	expected := func(collects int, age string, stale int) string {
+		return fmt.Sprintf(`
+# HELP collector_snapshot_age_seconds Seconds since the snapshot of the collector served to scrapes was refreshed
+# TYPE collector_snapshot_age_seconds gauge
+collector_snapshot_age_seconds%s %s
+# HELP collector_snapshot_stale The snapshot of the collector is not refreshed for two poll intervals
+# TYPE collector_snapshot_stale gauge
+collector_snapshot_stale%s %d
+# HELP test_collects Collects of test collector
+# TYPE test_collects gauge
+test_collects %d
+`, l, age, l, stale, collects)
+	}
+	// the snapshot is served without collecting again
+	now = now.Add(5 * time.Second)
+	assert.NoError(testutil.CollectAndCompare(p, strings.NewReader(expected(1, "5", 0))))
+	now = now.Add(20 * time.Second)
+	assert.NoError(testutil.CollectAndCompare(p, strings.NewReader(expected(1, "25", 1))))
+	assert.Equal(int32(1), atomic.LoadInt32(&slow.calls))
+
+	p.refresh()
+	assert.NoError(testutil.CollectAndCompare(p, strings.NewReader(expected(2, "0", 0))))
+}
+
+func TestPollingCollectorStart(t *testing.T) {
+	assert := asserting.New(t)
+	constants := testConstants(testOptions(t, "--poll-interval", "10ms"), Lookup)
+	slow := &testSlowCollector{desc: prometheus.NewDesc("test_collects", "Collects of test collector", nil, nil), release: make(chan struct{})}
+	close(slow.release)
+	p := NewPollingCollector(constants, "test", slow)
+	go p.Start()
+	assert.Eventually(func() bool { return atomic.LoadInt32(&slow.calls) >= 3 }, time.Second, time.Millisecond)
+	p.Stop()
+	p.Stop()
+
+	disabled := NewPollingCollector(testConstants(testOptions(t), Lookup), "test", slow)
+	disabled.Start()
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"net/http"
+)
+
+// ProbeHandler serves /probe?target=...&module=..., collecting metrics of a remote node like blackbox_exporter,
+// with the collector of the module on a fresh registry, so that only metrics of the target are returned
+type ProbeHandler struct {
+	options *Options
+	modules map[string]ProbeModule
+}
+
+// NewProbeHandler returns handler of /probe with modules in config, only the targets of a module are probed by it
+func NewProbeHandler(options *Options, modules map[string]ProbeModule) *ProbeHandler {
+	return &ProbeHandler{options: options, modules: modules}
+}
+
+func (h *ProbeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	target := query.Get("target")
+	if target == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	moduleName := query.Get("module")
+	if moduleName == "" {
+		moduleName = "api"
+	}
+	module, ok := h.modules[moduleName]
+	if !ok {
+		http.Error(w, "unknown module "+moduleName, http.StatusBadRequest)
+		return
+	}
+	// the exporter would otherwise call any address for anyone reaching it
+	if !module.Allowed(target) {
+		http.Error(w, "target "+target+" is not allowed by module "+moduleName, http.StatusForbidden)
+		return
+	}
+
+	constants := h.targetConstants(target, module)
+	registry := prometheus.NewRegistry()
+	switch module.Prober {
+	case "api":
+		c := NewAPICollector(constants)
+		if len(module.Methods) > 0 {
+			c.methods = make(map[apiclient.MethodName]bool)
+			for _, m := range module.Methods {
+				c.methods[apiclient.MethodName(m)] = true
+			}
+		}
+		registry.MustRegister(c)
+	case "admin":
+		c := newAdminProbeCollector(constants, module)
+		if c.client != nil {
+			defer c.client.Close()
+		}
+		registry.MustRegister(c)
+	}
+	log.WithField("target", target).WithField("module", moduleName).Debug("probe target")
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// newAdminProbeCollector returns the admin collector of a target, calling only the admin server of the target
+func newAdminProbeCollector(constants *Constants, module ProbeModule) *AdminCollector {
+	c := NewAdminCollector(constants)
+	// the api of the target is unknown, the one in options would be the default of the local node
+	c.apiClient = nil
+	// a probe calls the target once, probing methods first would only double the calls
+	c.capabilities = nil
+	if len(module.Methods) > 0 {
+		c.methods = make(map[adminclient.MethodName]bool)
+		for _, m := range module.Methods {
+			c.methods[adminclient.MethodName(m)] = true
+		}
+	}
+	return c
+}
+
+// targetConstants returns constants of the target, taking options of the exporter with endpoints set to the target.
+// Options only for the node of the exporter are dropped, and calls are not observed by rpc metrics,
+// which would otherwise get series of every target probed
+func (h *ProbeHandler) targetConstants(target string, module ProbeModule) *Constants {
+	options := *h.options
+	options.apiHeaders = nil
+	options.apiBasicAuth = ""
+	options.apiFallbacks = nil
+	options.referenceEndpoints = nil
+	options.pubKey = ""
+	options.zilliqaConstants = ""
+	options.rpcObserver = nil
+	// a failed probe is a failure of the target, as a client sees it
+	options.rpcRetries = 0
+	// clients live for a single probe, a breaker would never be shared between probes,
+	// and breakers and connections of the node of the exporter are not taken for the target
+	options.rpcBreakerFailures = 0
+	options.endpoints = nil
+	if module.Timeout > 0 {
+		options.rpcTimeout = module.Timeout
+	}
+	nodeType := UnknownNodeType
+	if module.Prober == "api" {
+		options.apiEndpoint = target
+		// targets of api module are taken as lookups, which serve the JsonRPC API
+		nodeType = Lookup
+	} else {
+		options.adminEndpoint = target
+		options.apiEndpoint = ""
+	}
+	return &Constants{options: &options, nodeType: nodeType}
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, h *ProbeHandler, target, module string) (int, string) {
+	query := url.Values{}
+	if target != "" {
+		query.Set("target", target)
+	}
+	if module != "" {
+		query.Set("module", module)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil))
+	body, err := ioutil.ReadAll(w.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, string(body)
+}
+
+func TestProbeHandler(t *testing.T) {
+	assert := asserting.New(t)
+	apiServer := newTestAPIServer()
+	defer apiServer.Close()
+	adminServer := jsonrpctest.NewTCPServer()
+	defer adminServer.Close()
+	adminServer.SetResult("GetNodeType", "Seed")
+	adminServer.SetResult("GetCurrentMiniEpoch", "1934")
+	adminServer.SetResult("GetCurrentDSEpoch", "20")
+
+	options := testOptions(t, "--api", "127.0.0.1:1", "--admin", "127.0.0.1:1")
+	options.SetRPCObserver(NewRPCMetrics(testConstants(options, Lookup)))
+	h := NewProbeHandler(options, map[string]ProbeModule{
+		"api":   {Prober: "api", Targets: []string{"127.0.0.1"}},
+		"block": {Prober: "api", Timeout: time.Second, Methods: []string{"GetLatestTxBlock"}, Targets: []string{"127.0.0.1"}},
+		"admin": {Prober: "admin", Methods: []string{"GetCurrentMiniEpoch"}, Targets: []string{adminServer.Addr(), "127.0.0.1:1"}},
+	})
+
+	code, body := probe(t, h, apiServer.URL, "")
+	assert.Equal(http.StatusOK, code)
+	assert.Contains(body, `api_server_up{cluster_name="",endpoint="`+apiServer.URL+`"`)
+	assert.Contains(body, "\nepoch{")
+	assert.Contains(body, "\nnetwork_id{")
+	assert.NotContains(body, "node_info")
+	assert.NotContains(body, "rpc_")
+	assert.Equal(1, apiServer.Calls("GetNetworkId"))
+
+	code, body = probe(t, h, apiServer.URL, "block")
+	assert.Equal(http.StatusOK, code)
+	assert.Contains(body, "\nlatest_txblock_timestamp{")
+	assert.NotContains(body, "network_id")
+	assert.Equal(1, apiServer.Calls("GetNetworkId"))
+	assert.Equal(2, apiServer.Calls("GetLatestTxBlock"))
+
+	code, body = probe(t, h, adminServer.Addr(), "admin")
+	assert.Equal(http.StatusOK, code)
+	assert.Contains(body, `admin_server_up{cluster_name="",endpoint="`+adminServer.Addr()+`"`)
+	assert.Contains(body, "\nepoch{")
+	assert.NotContains(body, "ds_epoch")
+	assert.Equal(0, adminServer.Calls("GetCurrentDSEpoch"))
+
+	code, body = probe(t, h, "127.0.0.1:1", "admin")
+	assert.Equal(http.StatusOK, code)
+	assert.Contains(body, `admin_server_up{cluster_name="",endpoint="127.0.0.1:1"`)
+
+	code, _ = probe(t, h, "", "api")
+	assert.Equal(http.StatusBadRequest, code)
+	code, _ = probe(t, h, apiServer.URL, "websocket")
+	assert.Equal(http.StatusBadRequest, code)
+
+	// only targets of the module are probed
+	code, _ = probe(t, h, "http://169.254.169.254/latest/meta-data", "api")
+	assert.Equal(http.StatusForbidden, code)
+	code, _ = probe(t, h, "127.0.0.1:2", "admin")
+	assert.Equal(http.StatusForbidden, code)
+	assert.Equal(1, adminServer.Calls("GetNodeType"))
+
+	// the local api is not called for an admin target
+	c := newAdminProbeCollector(h.targetConstants(adminServer.Addr(), h.modules["admin"]), h.modules["admin"])
+	defer c.client.Close()
+	assert.Nil(c.apiClient)
+}
+
+func TestProbeModuleAllowed(t *testing.T) {
+	assert := asserting.New(t)
+	api := ProbeModule{Prober: "api", Targets: []string{"api.zilliqa.com", "10.0.0.1:4201"}}
+	assert.True(api.Allowed("https://api.zilliqa.com"))
+	assert.True(api.Allowed("http://api.zilliqa.com:8080/path"))
+	assert.True(api.Allowed("10.0.0.1:4201"))
+	assert.False(api.Allowed("10.0.0.1:4301"))
+	assert.False(api.Allowed("http://api.zilliqa.com.evil.com"))
+	assert.False(api.Allowed("http://evil.com/?api.zilliqa.com"))
+
+	admin := ProbeModule{Prober: "admin", Targets: []string{"10.0.0.2:4301", "unix:///run/zilliqa.sock"}}
+	assert.True(admin.Allowed("10.0.0.2:4301"))
+	assert.True(admin.Allowed("unix:///run/zilliqa.sock"))
+	assert.False(admin.Allowed("10.0.0.2:22"))
+	assert.False(admin.Allowed("10.0.0.3:4301"))
+}
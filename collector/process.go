@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"github.com/shirou/gopsutil/process"
 	log "github.com/sirupsen/logrus"
@@ -9,14 +10,19 @@ import (
 )
 
 func GetZilliqaMainProcess(constants *Constants) *process.Process {
-	procs, err := GetProcesses("zilliqa", constants.P2PPort(), 4201, 4301)
+	return GetZilliqaMainProcessContext(context.Background(), constants)
+}
+
+// GetZilliqaMainProcessContext finds zilliqa main process, giving up scanning processes when ctx is done
+func GetZilliqaMainProcessContext(ctx context.Context, constants *Constants) *process.Process {
+	procs, err := GetProcessesContext(ctx, "zilliqa", constants.P2PPort(), 4201, 4301)
 	if err != nil {
 		log.WithError(err).Error("fail to get zilliqa main process")
 		return nil
 	}
 	if len(procs) > 0 {
 		proc := procs[0]
-		name, err := proc.Name()
+		name, err := proc.NameWithContext(ctx)
 		if err != nil {
 			log.WithError(err).Error("fail to get zilliqa main process")
 			return nil
@@ -31,13 +37,22 @@ func GetZilliqaMainProcess(constants *Constants) *process.Process {
 }
 
 func GetZilliqadProcess() *process.Process {
-	processes, err := process.Processes()
+	return GetZilliqadProcessContext(context.Background())
+}
+
+// GetZilliqadProcessContext finds zilliqad process, giving up scanning processes when ctx is done
+func GetZilliqadProcessContext(ctx context.Context) *process.Process {
+	processes, err := process.ProcessesWithContext(ctx)
 	if err != nil {
 		log.WithError(err).Error("fail to get zilliqad main process")
 		return nil
 	}
 	for _, proc := range processes {
-		name, err := proc.Name()
+		if ctx.Err() != nil {
+			log.WithError(ctx.Err()).Error("fail to get zilliqad main process")
+			return nil
+		}
+		name, err := proc.NameWithContext(ctx)
 		if err != nil {
 			log.WithError(err).Error("fail to get zilliqad main process")
 			return nil
@@ -51,7 +66,12 @@ func GetZilliqadProcess() *process.Process {
 
 // match port first, if no matched, return procs that matches name
 func GetProcesses(name string, port ...uint32) ([]*process.Process, error) {
-	processes, err := process.Processes()
+	return GetProcessesContext(context.Background(), name, port...)
+}
+
+// GetProcessesContext is GetProcesses stopped when ctx is done
+func GetProcessesContext(ctx context.Context, name string, port ...uint32) ([]*process.Process, error) {
+	processes, err := process.ProcessesWithContext(ctx)
 	if name == "" && len(port) == 0 {
 		return processes, err
 	}
@@ -62,7 +82,10 @@ func GetProcesses(name string, port ...uint32) ([]*process.Process, error) {
 	var nameMatched []*process.Process
 Loop:
 	for _, proc := range processes {
-		connections, err := proc.Connections()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		connections, err := proc.ConnectionsWithContext(ctx)
 		if err != nil {
 			continue
 		}
@@ -75,7 +98,7 @@ Loop:
 			}
 		}
 
-		name, err := proc.Name()
+		name, err := proc.NameWithContext(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shirou/gopsutil/cpu"
@@ -198,9 +199,19 @@ func (c *ProcessInfoCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *ProcessInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext collects process info, scanning processes until ctx is done
+func (c *ProcessInfoCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
 	log.Debug("start collecting process info")
-	process := GetZilliqaMainProcess(c.constants)
-	processd := GetZilliqadProcess()
+	process := GetZilliqaMainProcessContext(ctx, c.constants)
+	processd := GetZilliqadProcessContext(ctx)
+	if ctx.Err() != nil {
+		// not found in time is not known as not running
+		log.WithError(ctx.Err()).Error("fail to find zilliqa process in time")
+		return
+	}
 	if process == nil {
 		log.Error("no running zilliqa process found")
 		ch <- prometheus.MustNewConstMetric(c.processRunning, prometheus.GaugeValue, 0, append(c.constants.CommonLabelValues(), "", "0", "")...)
@@ -274,7 +285,7 @@ func (c *ProcessInfoCollector) Collect(ch chan<- prometheus.Metric) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		connections, err := process.Connections()
+		connections, err := process.ConnectionsWithContext(ctx)
 		if err != nil {
 			return
 		}
@@ -478,6 +489,10 @@ func GetPortFromCmdline(cmdline []string) (int, error) {
 	return strconv.Atoi(value)
 }
 
+func GetPubKeyFromCmdline(cmdline []string) string {
+	return GetParamValueFromCmdline(cmdline, "--pubk", "-u")
+}
+
 func GetParamValueFromCmdline(cmdline []string, param ...string) string {
 	for i, arg := range cmdline {
 		for _, p := range param {
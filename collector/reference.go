@@ -0,0 +1,226 @@
+package collector
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"strconv"
+	"sync"
+)
+
+// chainHead is the head of chain seen by a node
+type chainHead struct {
+	epoch   uint64 // current TX epoch, the latest TX block is epoch - 1
+	dsEpoch uint64
+	// timestamp of the latest TX block in microseconds, 0 if unknown
+	timestamp uint64
+}
+
+// ReferenceCollector compares epochs of the node with reference lookups on every scrape,
+// so that a node is known out of sync without other nodes scraped by the same Prometheus
+type ReferenceCollector struct {
+	options    *Options
+	constants  *Constants
+	references []*apiclient.Client
+	// clients of the node
+	apiClient   *apiclient.Client
+	adminClient *adminclient.Client
+
+	referenceUp        *prometheus.Desc
+	referenceEpoch     *prometheus.Desc
+	referenceDSEpoch   *prometheus.Desc
+	referenceTimestamp *prometheus.Desc
+	epochLag           *prometheus.Desc
+	dsEpochLag         *prometheus.Desc
+	secondsBehind      *prometheus.Desc
+}
+
+func NewReferenceCollector(constants *Constants) *ReferenceCollector {
+	commonLabels := constants.CommonLabels()
+	c := &ReferenceCollector{
+		options:    constants.options,
+		constants:  constants,
+		references: constants.options.GetReferenceClients(),
+		referenceUp: prometheus.NewDesc(
+			"reference_up", "JsonRPC API of the reference lookup up and running",
+			append([]string{"reference"}, commonLabels...), nil,
+		),
+		referenceEpoch: prometheus.NewDesc(
+			"reference_epoch", "Current TX block number of the reference lookup",
+			append([]string{"reference"}, commonLabels...), nil,
+		),
+		referenceDSEpoch: prometheus.NewDesc(
+			"reference_ds_epoch", "Current DS block number of the reference lookup",
+			append([]string{"reference"}, commonLabels...), nil,
+		),
+		referenceTimestamp: prometheus.NewDesc(
+			"reference_latest_txblock_timestamp", "The timestamp of the latest tx block of the reference lookup",
+			append([]string{"reference"}, commonLabels...), nil,
+		),
+		epochLag: prometheus.NewDesc(
+			"reference_epoch_lag", "TX epochs the node is behind the most advanced reference lookup",
+			commonLabels, nil,
+		),
+		dsEpochLag: prometheus.NewDesc(
+			"reference_ds_epoch_lag", "DS epochs the node is behind the most advanced reference lookup",
+			commonLabels, nil,
+		),
+		secondsBehind: prometheus.NewDesc(
+			"reference_seconds_behind", "Seconds between the latest tx block of the node and of the most advanced reference lookup",
+			commonLabels, nil,
+		),
+	}
+	if len(c.references) > 0 {
+		c.apiClient = constants.options.GetAPIClient()
+		c.adminClient = constants.options.GetAdminClient()
+	}
+	return c
+}
+
+func (c *ReferenceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.referenceUp
+	ch <- c.referenceEpoch
+	ch <- c.referenceDSEpoch
+	ch <- c.referenceTimestamp
+	ch <- c.epochLag
+	ch <- c.dsEpochLag
+	ch <- c.secondsBehind
+}
+
+func (c *ReferenceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+// CollectContext compares chain heads, calls to the node and references are canceled when ctx is done
+func (c *ReferenceCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if len(c.references) == 0 {
+		return
+	}
+	labels := c.constants.CommonLabelValues()
+	ctx, cancel := context.WithTimeout(ctx, c.options.RPCTimeout())
+	defer cancel()
+
+	heads := make([]*chainHead, len(c.references))
+	var wg sync.WaitGroup
+	for i, ref := range c.references {
+		wg.Add(1)
+		go func(i int, ref *apiclient.Client) {
+			defer wg.Done()
+			head, err := apiChainHead(ctx, ref)
+			if err != nil {
+				logRPCError(err, "fail to get chain head of reference "+ref.Address())
+				return
+			}
+			heads[i] = head
+		}(i, ref)
+	}
+	local, err := c.localChainHead(ctx)
+	if err != nil {
+		logRPCError(err, "fail to get chain head of the node")
+	}
+	wg.Wait()
+
+	var best *chainHead
+	var bestRef *apiclient.Client
+	for i, ref := range c.references {
+		refLabels := append([]string{ref.Address()}, labels...)
+		head := heads[i]
+		if head == nil {
+			ch <- prometheus.MustNewConstMetric(c.referenceUp, prometheus.GaugeValue, 0, refLabels...)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.referenceUp, prometheus.GaugeValue, 1, refLabels...)
+		ch <- prometheus.MustNewConstMetric(c.referenceEpoch, prometheus.GaugeValue, float64(head.epoch), refLabels...)
+		ch <- prometheus.MustNewConstMetric(c.referenceDSEpoch, prometheus.GaugeValue, float64(head.dsEpoch), refLabels...)
+		ch <- prometheus.MustNewConstMetric(c.referenceTimestamp, prometheus.GaugeValue, float64(head.timestamp)/1000, refLabels...)
+		if best == nil || head.epoch > best.epoch {
+			best, bestRef = head, ref
+		}
+	}
+	if best == nil || local == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.epochLag, prometheus.GaugeValue, float64(best.epoch)-float64(local.epoch), labels...)
+	ch <- prometheus.MustNewConstMetric(c.dsEpochLag, prometheus.GaugeValue, float64(best.dsEpoch)-float64(local.dsEpoch), labels...)
+
+	if local.timestamp == 0 && local.epoch > 0 {
+		// the admin API has no block timestamps, take the one of the latest block of the node from the reference
+		block, err := bestRef.GetTxBlockContext(ctx, local.epoch-1)
+		if err != nil {
+			logRPCError(err, "fail to get tx block of the node from reference "+bestRef.Address())
+			return
+		}
+		local.timestamp, _ = strconv.ParseUint(block.Header.Timestamp, 10, 64)
+	}
+	if local.timestamp == 0 {
+		return
+	}
+	behind := (float64(best.timestamp) - float64(local.timestamp)) / 1e6
+	ch <- prometheus.MustNewConstMetric(c.secondsBehind, prometheus.GaugeValue, behind, labels...)
+}
+
+// localChainHead gets chain head of the node from JsonRPC API for lookups, or from admin API for other nodes
+func (c *ReferenceCollector) localChainHead(ctx context.Context) (*chainHead, error) {
+	if IsGeneralLookup(c.constants.NodeType()) {
+		if c.apiClient == nil {
+			return nil, errors.New("API endpoint not set")
+		}
+		return apiChainHead(ctx, c.apiClient)
+	}
+	if c.adminClient == nil {
+		return nil, errors.New("admin endpoint not set")
+	}
+	return adminChainHead(ctx, c.adminClient)
+}
+
+func apiChainHead(ctx context.Context, cli *apiclient.Client) (*chainHead, error) {
+	infoReq := apiclient.NewGetBlockchainInfoReq()
+	txBlockReq := apiclient.NewGetLatestTxBlockReq()
+	batch, err := cli.CallBatchContext(ctx, infoReq, txBlockReq)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := batch.Get(infoReq)
+	if err != nil {
+		return nil, err
+	}
+	info, err := apiclient.ParseBlockchainInfo(resp)
+	if err != nil {
+		return nil, err
+	}
+	head := &chainHead{}
+	if head.epoch, err = strconv.ParseUint(info.CurrentMiniEpoch, 10, 64); err != nil {
+		return nil, errors.Wrap(err, "invalid CurrentMiniEpoch")
+	}
+	if head.dsEpoch, err = strconv.ParseUint(info.CurrentDSEpoch, 10, 64); err != nil {
+		return nil, errors.Wrap(err, "invalid CurrentDSEpoch")
+	}
+	block, err := batchTxBlock(batch, txBlockReq)
+	if err != nil {
+		return nil, err
+	}
+	if head.timestamp, err = strconv.ParseUint(block.Header.Timestamp, 10, 64); err != nil {
+		return nil, errors.Wrap(err, "invalid timestamp of the latest tx block")
+	}
+	return head, nil
+}
+
+func adminChainHead(ctx context.Context, cli *adminclient.Client) (*chainHead, error) {
+	epochReq := adminclient.NewGetCurrentMiniEpochReq()
+	dsEpochReq := adminclient.NewGetCurrentDSEpochReq()
+	batch, err := cli.CallBatchContext(ctx, epochReq, dsEpochReq)
+	if err != nil {
+		return nil, err
+	}
+	epoch, err := batchFloat64(batch, epochReq)
+	if err != nil {
+		return nil, err
+	}
+	dsEpoch, err := batchFloat64(batch, dsEpochReq)
+	if err != nil {
+		return nil, err
+	}
+	return &chainHead{epoch: uint64(epoch), dsEpoch: uint64(dsEpoch)}, nil
+}
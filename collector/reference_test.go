@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// timestamp of the latest tx block of newTestAPIServer, in microseconds
+const testTxBlockTimestamp = 1600000000000000
+
+func newTestReferenceServer(epoch, dsEpoch, timestamp uint64) *jsonrpctest.HTTPServer {
+	server := jsonrpctest.NewHTTPServer()
+	server.SetResult("GetBlockchainInfo", map[string]interface{}{
+		"CurrentDSEpoch":   strconv.FormatUint(dsEpoch, 10),
+		"CurrentMiniEpoch": strconv.FormatUint(epoch, 10),
+	})
+	server.SetResult("GetLatestTxBlock", map[string]interface{}{
+		"header": map[string]interface{}{"BlockNum": strconv.FormatUint(epoch-1, 10), "Timestamp": strconv.FormatUint(timestamp, 10)},
+	})
+	return server
+}
+
+func TestReferenceCollector(t *testing.T) {
+	assert := asserting.New(t)
+	local := newTestAPIServer()
+	defer local.Close()
+	ahead := newTestReferenceServer(1940, 21, testTxBlockTimestamp+60e6)
+	defer ahead.Close()
+	behind := newTestReferenceServer(1930, 20, testTxBlockTimestamp-60e6)
+	defer behind.Close()
+	down := jsonrpctest.NewHTTPServer()
+	down.Close()
+
+	constants := testConstants(testOptions(t, "--api", local.URL, "--rpc-retries", "0",
+		"--reference-api", ahead.URL, "--reference-api", behind.URL, "--reference-api", down.URL), Lookup)
+	c := NewReferenceCollector(constants)
+	expected := fmt.Sprintf(`
+# HELP reference_up JsonRPC API of the reference lookup up and running
+# TYPE reference_up gauge
+reference_up%s 1
+reference_up%s 1
+reference_up%s 0
+# HELP reference_epoch Current TX block number of the reference lookup
+# TYPE reference_epoch gauge
+reference_epoch%s 1940
+reference_epoch%s 1930
+# HELP reference_epoch_lag TX epochs the node is behind the most advanced reference lookup
+# TYPE reference_epoch_lag gauge
+reference_epoch_lag%s 6
+# HELP reference_ds_epoch_lag DS epochs the node is behind the most advanced reference lookup
+# TYPE reference_ds_epoch_lag gauge
+reference_ds_epoch_lag%s 1
+# HELP reference_seconds_behind Seconds between the latest tx block of the node and of the most advanced reference lookup
+# TYPE reference_seconds_behind gauge
+reference_seconds_behind%s 60
+`,
+		labelText(constants, "reference", ahead.URL),
+		labelText(constants, "reference", behind.URL),
+		labelText(constants, "reference", down.URL),
+		labelText(constants, "reference", ahead.URL),
+		labelText(constants, "reference", behind.URL),
+		labelText(constants), labelText(constants), labelText(constants),
+	)
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"reference_up", "reference_epoch", "reference_epoch_lag", "reference_ds_epoch_lag", "reference_seconds_behind"))
+	// the latest block of the node is known from its own API
+	assert.Equal(0, ahead.Calls("GetTxBlock"))
+}
+
+func TestReferenceCollectorAdmin(t *testing.T) {
+	assert := asserting.New(t)
+	admin := jsonrpctest.NewTCPServer()
+	defer admin.Close()
+	admin.SetResult("GetCurrentMiniEpoch", "1930")
+	admin.SetResult("GetCurrentDSEpoch", "20")
+	reference := newTestReferenceServer(1940, 21, testTxBlockTimestamp+60e6)
+	defer reference.Close()
+	var asked []string
+	reference.SetHandler("GetTxBlock", func(_ int, params json.RawMessage) (interface{}, error) {
+		_ = json.Unmarshal(params, &asked)
+		return map[string]interface{}{
+			"header": map[string]interface{}{"BlockNum": "1929", "Timestamp": strconv.FormatUint(testTxBlockTimestamp-40e6, 10)},
+		}, nil
+	})
+
+	constants := testConstants(testOptions(t, "--admin", admin.Addr(), "--reference-api", reference.URL), Normal)
+	c := NewReferenceCollector(constants)
+	expected := fmt.Sprintf(`
+# HELP reference_epoch_lag TX epochs the node is behind the most advanced reference lookup
+# TYPE reference_epoch_lag gauge
+reference_epoch_lag%s 10
+# HELP reference_seconds_behind Seconds between the latest tx block of the node and of the most advanced reference lookup
+# TYPE reference_seconds_behind gauge
+reference_seconds_behind%s 100
+`, labelText(constants), labelText(constants))
+	assert.NoError(testutil.CollectAndCompare(c, strings.NewReader(expected), "reference_epoch_lag", "reference_seconds_behind"))
+	assert.Equal([]string{"1929"}, asked)
+}
+
+func TestReferenceCollectorDisabled(t *testing.T) {
+	constants := testConstants(testOptions(t), Lookup)
+	asserting.Equal(t, 0, testutil.CollectAndCount(NewReferenceCollector(constants)))
+}
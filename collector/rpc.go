@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+)
+
+// logRPCError logs err, at debug level if the call was skipped by circuit breaker, as the breaker already logged it
+func logRPCError(err error, msg string) {
+	if errors.Is(err, jsonrpc.ErrCircuitOpen) {
+		log.WithError(err).Debug(msg)
+		return
+	}
+	log.WithError(err).Error(msg)
+}
+
+// collectBreakerState exports breaker state as a state set
+func collectBreakerState(ch chan<- prometheus.Metric, desc *prometheus.Desc, breaker *jsonrpc.CircuitBreaker, endpoint string, labels []string) {
+	if breaker == nil || breaker.Threshold <= 0 {
+		return
+	}
+	current := breaker.State()
+	for _, state := range jsonrpc.BreakerStates {
+		var value float64
+		if state == current {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value,
+			append([]string{endpoint, state.String()}, labels...)...)
+	}
+}
+
+// helpers to get typed results of a request from batch responses
+
+func batchFloat64(batch *jsonrpc.BatchResponse, req *jsonrpc.Request) (float64, error) {
+	resp, err := batch.Get(req)
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetFloat64()
+}
+
+func batchString(batch *jsonrpc.BatchResponse, req *jsonrpc.Request) (string, error) {
+	resp, err := batch.Get(req)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString()
+}
+
+func batchObject(batch *jsonrpc.BatchResponse, req *jsonrpc.Request, obj interface{}) error {
+	resp, err := batch.Get(req)
+	if err != nil {
+		return err
+	}
+	return resp.GetObject(obj)
+}
+
+func batchTxBlock(batch *jsonrpc.BatchResponse, req *jsonrpc.Request) (*core.TxBlock, error) {
+	resp, err := batch.Get(req)
+	if err != nil {
+		return nil, err
+	}
+	return apiclient.ParseTxBlock(resp)
+}
+
+func batchDSBlock(batch *jsonrpc.BatchResponse, req *jsonrpc.Request) (*core.DSBlock, error) {
+	resp, err := batch.Get(req)
+	if err != nil {
+		return nil, err
+	}
+	return apiclient.ParseDSBlock(resp)
+}
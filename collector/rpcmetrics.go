@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"sync"
+	"time"
+)
+
+var rpcDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type rpcCallKey struct {
+	endpoint string
+	method   string
+	outcome  string
+}
+
+// RPCMetrics observes every outgoing JsonRPC call of the API and admin clients
+type RPCMetrics struct {
+	constants *Constants
+
+	mu    sync.Mutex
+	calls map[rpcCallKey]*histogramStats
+
+	requestDuration *prometheus.Desc
+	requestsTotal   *prometheus.Desc
+}
+
+func NewRPCMetrics(constants *Constants) *RPCMetrics {
+	commonLabels := constants.CommonLabels()
+	return &RPCMetrics{
+		constants: constants,
+		calls:     make(map[rpcCallKey]*histogramStats),
+		requestDuration: prometheus.NewDesc(
+			"rpc_request_duration_seconds", "Duration of JsonRPC requests sent by the exporter",
+			append([]string{"endpoint", "method", "outcome"}, commonLabels...), nil,
+		),
+		requestsTotal: prometheus.NewDesc(
+			"rpc_requests_total", "JsonRPC requests sent by the exporter",
+			append([]string{"endpoint", "method", "outcome"}, commonLabels...), nil,
+		),
+	}
+}
+
+func (m *RPCMetrics) ObserveCall(endpoint, method string, duration time.Duration, err error) {
+	key := rpcCallKey{endpoint: endpoint, method: method, outcome: jsonrpc.Outcome(err)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.calls[key]
+	if !ok {
+		stats = newHistogramStats(rpcDurationBuckets)
+		m.calls[key] = stats
+	}
+	stats.observe(rpcDurationBuckets, duration.Seconds())
+}
+
+func (m *RPCMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.requestDuration
+	ch <- m.requestsTotal
+}
+
+func (m *RPCMetrics) Collect(ch chan<- prometheus.Metric) {
+	labels := m.constants.CommonLabelValues()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, stats := range m.calls {
+		labelValues := append([]string{key.endpoint, key.method, key.outcome}, labels...)
+		ch <- stats.metric(m.requestDuration, rpcDurationBuckets, labelValues...)
+		ch <- prometheus.MustNewConstMetric(m.requestsTotal, prometheus.CounterValue, float64(stats.count), labelValues...)
+	}
+}
@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ScrapeTimeoutHeader is set by Prometheus to the scrape timeout in seconds
+const ScrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// ContextCollector is a collector calling the node, which stops when ctx is done
+type ContextCollector interface {
+	prometheus.Collector
+	CollectContext(ctx context.Context, ch chan<- prometheus.Metric)
+}
+
+type namedCollector struct {
+	name      string
+	collector ContextCollector
+}
+
+// ScrapeHandler serves /metrics of gatherer and collectors calling the node,
+// which are stopped at the scrape timeout of Prometheus less --scrape-timeout-offset.
+// Metrics collected before the deadline are returned, with collector_timeout set for the collector stopped
+type ScrapeHandler struct {
+	constants  *Constants
+	gatherer   prometheus.Gatherer
+	offset     time.Duration
+	collectors []namedCollector
+
+	timeout *prometheus.Desc
+}
+
+func NewScrapeHandler(constants *Constants, gatherer prometheus.Gatherer) *ScrapeHandler {
+	return &ScrapeHandler{
+		constants: constants,
+		gatherer:  gatherer,
+		offset:    constants.options.scrapeTimeoutOffset,
+		timeout: prometheus.NewDesc(
+			"collector_timeout", "The collector did not finish before the scrape timeout, its metrics are partial",
+			append([]string{"collector"}, constants.CommonLabels()...), nil,
+		),
+	}
+}
+
+// Register adds a collector called with the deadline of every scrape, it should be called before serving
+func (h *ScrapeHandler) Register(name string, c ContextCollector) {
+	h.collectors = append(h.collectors, namedCollector{name: name, collector: c})
+}
+
+func (h *ScrapeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if timeout := h.scrapeTimeout(r); timeout > 0 {
+		var cancel context.CancelFunc
+		// calls stopped by the scrape timeout are not failures of the node, unlike ones past --rpc-timeout
+		ctx, cancel = jsonrpc.WithCallerTimeout(ctx, timeout)
+		defer cancel()
+	}
+	registry := prometheus.NewRegistry()
+	for _, c := range h.collectors {
+		registry.MustRegister(&scrapeCollector{handler: h, named: c, ctx: ctx})
+	}
+	promhttp.HandlerFor(prometheus.Gatherers{h.gatherer, registry}, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// scrapeTimeout returns the time collectors have in a scrape, 0 if Prometheus did not tell its timeout
+func (h *ScrapeHandler) scrapeTimeout(r *http.Request) time.Duration {
+	header := r.Header.Get(ScrapeTimeoutHeader)
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		log.WithField("header", header).Warn("invalid " + ScrapeTimeoutHeader)
+		return 0
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > h.offset {
+		timeout -= h.offset
+	}
+	return timeout
+}
+
+// scrapeCollector collects a collector with the context of a scrape, it has no descriptors as registered per scrape
+type scrapeCollector struct {
+	handler *ScrapeHandler
+	named   namedCollector
+	ctx     context.Context
+}
+
+func (c *scrapeCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *scrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		c.named.collector.CollectContext(c.ctx, metrics)
+		close(metrics)
+	}()
+	var timeout float64
+Loop:
+	for {
+		select {
+		case m, ok := <-metrics:
+			if !ok {
+				break Loop
+			}
+			ch <- m
+		case <-c.ctx.Done():
+			log.WithField("collector", c.named.name).Warn("collector stopped at scrape timeout")
+			timeout = 1
+			// the collector returns soon after ctx is done, metrics collected too late are dropped
+			go func() {
+				for range metrics {
+				}
+			}()
+			break Loop
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.handler.timeout, prometheus.GaugeValue, timeout,
+		append([]string{c.named.name}, c.handler.constants.CommonLabelValues()...)...)
+}
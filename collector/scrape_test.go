@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStuckCollector sends a metric, then waits for release ignoring ctx, like a collector stuck in a call
+type testStuckCollector struct {
+	desc    *prometheus.Desc
+	release chan struct{}
+}
+
+func (c *testStuckCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *testStuckCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(context.Background(), ch)
+}
+
+func (c *testStuckCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1, "first")
+	if c.release != nil {
+		<-c.release
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 2, "late")
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	assert := asserting.New(t)
+	h := NewScrapeHandler(testConstants(testOptions(t), Lookup), prometheus.NewRegistry())
+	for header, expected := range map[string]time.Duration{
+		"":    0,
+		"10":  9500 * time.Millisecond,
+		"0.2": 200 * time.Millisecond,
+		"abc": 0,
+		"-1":  0,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			r.Header.Set(ScrapeTimeoutHeader, header)
+		}
+		assert.Equal(expected, h.scrapeTimeout(r), header)
+	}
+}
+
+func TestScrapeHandler(t *testing.T) {
+	assert := asserting.New(t)
+	constants := testConstants(testOptions(t, "--scrape-timeout-offset", "0"), Lookup)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gathered", Help: "Gathered metric"}))
+	h := NewScrapeHandler(constants, registry)
+	stuck := &testStuckCollector{
+		desc:    prometheus.NewDesc("test_stuck", "Metrics of stuck collector", []string{"step"}, nil),
+		release: make(chan struct{}),
+	}
+	defer close(stuck.release)
+	h.Register("stuck", stuck)
+	h.Register("quick", &testStuckCollector{desc: prometheus.NewDesc("test_quick", "Metrics of quick collector", []string{"step"}, nil)})
+
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set(ScrapeTimeoutHeader, "0.1")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(w, r)
+	assert.Less(int64(time.Since(start)), int64(time.Second))
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	text := string(body)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(text, "test_gathered 0")
+	assert.Contains(text, `test_stuck{step="first"} 1`)
+	assert.NotContains(text, `test_stuck{step="late"}`)
+	assert.Contains(text, `test_quick{step="late"} 2`)
+	assert.Regexp(`collector_timeout\{[^}]*collector="stuck"[^}]*\} 1`, text)
+	assert.Regexp(`collector_timeout\{[^}]*collector="quick"[^}]*\} 0`, text)
+}
+
+func TestAPICollectorContext(t *testing.T) {
+	assert := asserting.New(t)
+	server := newTestAPIServer()
+	defer server.Close()
+	server.SetLatency(time.Second)
+	constants := testConstants(testOptions(t, "--api", server.URL, "--rpc-retries", "0"), Lookup)
+	c := NewAPICollector(constants)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	expected := fmt.Sprintf(`
+# HELP api_server_up JsonRPC API server up and running
+# TYPE api_server_up gauge
+api_server_up%s 0
+`, labelText(constants, "endpoint", server.URL))
+	assert.NoError(testutil.CollectAndCompare(&testContextCollector{c, ctx}, strings.NewReader(expected), "api_server_up"))
+	assert.Less(int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+// testContextCollector collects a ContextCollector with ctx
+type testContextCollector struct {
+	ContextCollector
+	ctx context.Context
+}
+
+func (c *testContextCollector) Collect(ch chan<- prometheus.Metric) {
+	c.CollectContext(c.ctx, ch)
+}
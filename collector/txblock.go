@@ -0,0 +1,288 @@
+package collector
+
+import (
+	"context"
+	"github.com/Zilliqa/gozilliqa-sdk/core"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/apiclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"strconv"
+	"sync"
+)
+
+var (
+	txBlockTransactionsBuckets = []float64{0, 1, 10, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+	txBlockGasUsedBuckets      = []float64{0, 10, 100, 1e3, 1e4, 1e5, 1e6, 1e7}
+	txBlockMicroBlocksBuckets  = []float64{1, 2, 3, 4, 5, 6, 8, 10, 15, 20}
+	txBlockIntervalBuckets     = []float64{5, 10, 15, 20, 30, 45, 60, 90, 120, 300, 600}
+	txBlocksPerDSEpochBuckets  = []float64{10, 25, 50, 75, 90, 99, 100, 101, 110, 150, 200}
+	dsEpochDurationBuckets     = []float64{300, 600, 1200, 1800, 2700, 3600, 5400, 7200, 10800, 21600}
+)
+
+// txBlockTrack is the previous walked TX block, and the DS epoch it is in
+type txBlockTrack struct {
+	num       uint64
+	timestamp uint64 // in microseconds, 0 if unknown
+	dsNum     string
+
+	// TX blocks walked in the DS epoch, and timestamp of the first one
+	epochBlocks uint64
+	epochStart  uint64
+	// the first TX block of the DS epoch is walked, so that the epoch is complete once the next one begins
+	epochComplete bool
+}
+
+// TxBlockFollower walks every new TX block from JsonRPC API,
+// so that throughput is counted for every block rather than sampled by scrapes
+type TxBlockFollower struct {
+	options   *Options
+	constants *Constants
+	client    *apiclient.Client
+	follower  *blockFollower
+
+	mu                sync.Mutex
+	transactions      *histogramStats
+	gasUsed           *histogramStats
+	microBlocks       *histogramStats
+	rewards           float64
+	shardTransactions map[int]uint64
+	prev              *txBlockTrack // nil before the first walked block
+	intervals         *histogramStats
+	epochBlocks       *histogramStats
+	epochDurations    *histogramStats
+
+	height                 *prometheus.Desc
+	skipped                *prometheus.Desc
+	blockTransactions      *prometheus.Desc
+	blockGasUsed           *prometheus.Desc
+	blockMicroBlocks       *prometheus.Desc
+	blockRewards           *prometheus.Desc
+	shardTransactionsTotal *prometheus.Desc
+	blockInterval          *prometheus.Desc
+	blocksPerDSEpoch       *prometheus.Desc
+	dsEpochDuration        *prometheus.Desc
+}
+
+func NewTxBlockFollower(constants *Constants) *TxBlockFollower {
+	commonLabels := constants.CommonLabels()
+	f := &TxBlockFollower{
+		options:           constants.options,
+		constants:         constants,
+		client:            constants.options.GetAPIClient(),
+		follower:          newBlockFollower("tx", constants.options.blockFollowInterval, constants.options.blockFollowMax),
+		transactions:      newHistogramStats(txBlockTransactionsBuckets),
+		gasUsed:           newHistogramStats(txBlockGasUsedBuckets),
+		microBlocks:       newHistogramStats(txBlockMicroBlocksBuckets),
+		shardTransactions: make(map[int]uint64),
+		intervals:         newHistogramStats(txBlockIntervalBuckets),
+		epochBlocks:       newHistogramStats(txBlocksPerDSEpochBuckets),
+		epochDurations:    newHistogramStats(dsEpochDurationBuckets),
+		height: prometheus.NewDesc(
+			"tx_block_follower_height", "The latest TX block walked by the block follower",
+			commonLabels, nil,
+		),
+		skipped: prometheus.NewDesc(
+			"tx_block_follower_skipped_total", "TX blocks skipped by the block follower when falling behind",
+			commonLabels, nil,
+		),
+		blockTransactions: prometheus.NewDesc(
+			"tx_block_transactions", "Transactions in a TX block",
+			commonLabels, nil,
+		),
+		blockGasUsed: prometheus.NewDesc(
+			"tx_block_gas_used", "Gas used by a TX block",
+			commonLabels, nil,
+		),
+		blockMicroBlocks: prometheus.NewDesc(
+			"tx_block_microblocks", "Micro blocks in a TX block",
+			commonLabels, nil,
+		),
+		blockRewards: prometheus.NewDesc(
+			"tx_block_rewards_total", "Rewards of TX blocks in Qa",
+			commonLabels, nil,
+		),
+		shardTransactionsTotal: prometheus.NewDesc(
+			"tx_block_shard_transactions_total", "Transactions in micro blocks of a shard, the DS committee is the shard numbered after the last one",
+			append([]string{"shard_id"}, commonLabels...), nil,
+		),
+		blockInterval: prometheus.NewDesc(
+			"tx_block_interval_seconds", "Time between consecutive TX blocks by their timestamps",
+			commonLabels, nil,
+		),
+		blocksPerDSEpoch: prometheus.NewDesc(
+			"tx_blocks_per_ds_epoch", "TX blocks in a DS epoch",
+			commonLabels, nil,
+		),
+		dsEpochDuration: prometheus.NewDesc(
+			"ds_epoch_duration_seconds", "Time from the first TX block of a DS epoch to the first one of the next DS epoch",
+			commonLabels, nil,
+		),
+	}
+	f.follower.latest = f.latest
+	f.follower.walk = f.walk
+	return f
+}
+
+// Start follows TX blocks until Stop is called, it blocks
+func (f *TxBlockFollower) Start() {
+	if f.client == nil {
+		log.Info("tx block follower disabled, API endpoint not set")
+		return
+	}
+	f.follower.start(func() bool {
+		nt := f.constants.NodeType()
+		return nt != UnknownNodeType && !IsGeneralLookup(nt)
+	})
+}
+
+func (f *TxBlockFollower) Stop() {
+	f.follower.close()
+}
+
+func (f *TxBlockFollower) latest(ctx context.Context) (uint64, error) {
+	block, err := f.client.GetLatestTxBlockContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(block.Header.BlockNum, 10, 64)
+}
+
+func (f *TxBlockFollower) walk(ctx context.Context, from, to uint64) uint64 {
+	blockReqs := make([]*jsonrpc.Request, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		blockReqs = append(blockReqs, apiclient.NewGetTxBlockReq(n))
+	}
+	batch, err := f.client.CallBatchContext(ctx, blockReqs...)
+	if err != nil {
+		logRPCError(err, "fail to get tx blocks")
+		return from
+	}
+	blocks := make([]*core.TxBlock, 0, len(blockReqs))
+	// transactions of blocks with any, by index of block
+	txnsReqs := make([]*jsonrpc.Request, len(blockReqs))
+	var pending []*jsonrpc.Request
+	for i, req := range blockReqs {
+		block, err := batchTxBlock(batch, req)
+		if err != nil {
+			log.WithError(err).WithField("block", from+uint64(i)).Error("fail to get tx block")
+			break
+		}
+		blocks = append(blocks, block)
+		if block.Header.NumTxns > 0 {
+			txnsReqs[i] = apiclient.NewGetTransactionsForTxBlockReq(from + uint64(i))
+			pending = append(pending, txnsReqs[i])
+		}
+	}
+
+	var txnsBatch *jsonrpc.BatchResponse
+	if len(pending) > 0 {
+		txnsBatch, err = f.client.CallBatchContext(ctx, pending...)
+		if err != nil {
+			logRPCError(err, "fail to get transactions of tx blocks")
+			return from
+		}
+	}
+	for i, block := range blocks {
+		var txns [][]string
+		if req := txnsReqs[i]; req != nil {
+			resp, err := txnsBatch.Get(req)
+			if err == nil {
+				txns, err = apiclient.ParseTransactionsForTxBlock(resp)
+			}
+			if err != nil {
+				log.WithError(err).WithField("block", from+uint64(i)).Error("fail to get transactions of tx block")
+				return from + uint64(i)
+			}
+		}
+		f.observe(block, txns)
+	}
+	return from + uint64(len(blocks))
+}
+
+// observe records block with its transaction hashes by micro block
+func (f *TxBlockFollower) observe(block *core.TxBlock, txns [][]string) {
+	gasUsed, _ := strconv.ParseFloat(block.Header.GasUsed, 64)
+	rewards, _ := strconv.ParseFloat(block.Header.Rewards, 64)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transactions.observe(txBlockTransactionsBuckets, float64(block.Header.NumTxns))
+	f.gasUsed.observe(txBlockGasUsedBuckets, gasUsed)
+	f.microBlocks.observe(txBlockMicroBlocksBuckets, float64(block.Header.NumMicroBlocks))
+	f.rewards += rewards
+	for i, mbTxns := range txns {
+		if i >= len(block.Body.MicroBlockInfos) {
+			log.WithField("block", block.Header.BlockNum).Warn("more micro blocks of transactions than micro block infos")
+			break
+		}
+		f.shardTransactions[block.Body.MicroBlockInfos[i].MicroBlockShardId] += uint64(len(mbTxns))
+	}
+	f.track(block)
+}
+
+// track observes intervals from the previous block and lengths of DS epochs, f.mu should be held.
+// Blocks skipped in between break the history, so the interval and the DS epoch across them are not observed
+func (f *TxBlockFollower) track(block *core.TxBlock) {
+	num, err := strconv.ParseUint(block.Header.BlockNum, 10, 64)
+	if err != nil {
+		f.prev = nil
+		return
+	}
+	timestamp, _ := strconv.ParseUint(block.Header.Timestamp, 10, 64)
+	prev := f.prev
+	if prev == nil || num != prev.num+1 {
+		f.prev = &txBlockTrack{num: num, timestamp: timestamp, dsNum: block.Header.DSBlockNum, epochBlocks: 1, epochStart: timestamp}
+		return
+	}
+	if prev.timestamp > 0 && timestamp >= prev.timestamp {
+		f.intervals.observe(txBlockIntervalBuckets, float64(timestamp-prev.timestamp)/1e6)
+	}
+	if block.Header.DSBlockNum == prev.dsNum {
+		prev.epochBlocks++
+	} else {
+		if prev.epochComplete {
+			f.epochBlocks.observe(txBlocksPerDSEpochBuckets, float64(prev.epochBlocks))
+			if prev.epochStart > 0 && timestamp >= prev.epochStart {
+				f.epochDurations.observe(dsEpochDurationBuckets, float64(timestamp-prev.epochStart)/1e6)
+			}
+		}
+		prev.dsNum, prev.epochBlocks, prev.epochStart, prev.epochComplete = block.Header.DSBlockNum, 1, timestamp, true
+	}
+	prev.num, prev.timestamp = num, timestamp
+}
+
+func (f *TxBlockFollower) Describe(ch chan<- *prometheus.Desc) {
+	ch <- f.height
+	ch <- f.skipped
+	ch <- f.blockTransactions
+	ch <- f.blockGasUsed
+	ch <- f.blockMicroBlocks
+	ch <- f.blockRewards
+	ch <- f.shardTransactionsTotal
+	ch <- f.blockInterval
+	ch <- f.blocksPerDSEpoch
+	ch <- f.dsEpochDuration
+}
+
+func (f *TxBlockFollower) Collect(ch chan<- prometheus.Metric) {
+	labels := f.constants.CommonLabelValues()
+	height, ok := f.follower.height()
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(f.height, prometheus.GaugeValue, float64(height), labels...)
+	ch <- prometheus.MustNewConstMetric(f.skipped, prometheus.CounterValue, float64(f.follower.skippedBlocks()), labels...)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch <- f.transactions.metric(f.blockTransactions, txBlockTransactionsBuckets, labels...)
+	ch <- f.gasUsed.metric(f.blockGasUsed, txBlockGasUsedBuckets, labels...)
+	ch <- f.microBlocks.metric(f.blockMicroBlocks, txBlockMicroBlocksBuckets, labels...)
+	ch <- prometheus.MustNewConstMetric(f.blockRewards, prometheus.CounterValue, f.rewards, labels...)
+	ch <- f.intervals.metric(f.blockInterval, txBlockIntervalBuckets, labels...)
+	ch <- f.epochBlocks.metric(f.blocksPerDSEpoch, txBlocksPerDSEpochBuckets, labels...)
+	ch <- f.epochDurations.metric(f.dsEpochDuration, dsEpochDurationBuckets, labels...)
+	for shard, count := range f.shardTransactions {
+		ch <- prometheus.MustNewConstMetric(f.shardTransactionsTotal, prometheus.CounterValue, float64(count),
+			append([]string{strconv.Itoa(shard)}, labels...)...)
+	}
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testTxBlocks are transactions by micro block of TX blocks, micro blocks are of shard 0 and DS committee (shard 1)
+var testTxBlocks = map[uint64][][]string{
+	101: {{"0x01", "0x02"}, {"0x03"}},
+	103: {{"0x04", "0x05", "0x06", "0x07", "0x08"}, {}},
+}
+
+func newTestTxBlockServer() *jsonrpctest.HTTPServer {
+	server := jsonrpctest.NewHTTPServer()
+	blockNum := func(params json.RawMessage) uint64 {
+		var nums []string
+		_ = json.Unmarshal(params, &nums)
+		n, _ := strconv.ParseUint(nums[0], 10, 64)
+		return n
+	}
+	server.SetHandler("GetTxBlock", func(call int, params json.RawMessage) (interface{}, error) {
+		n := blockNum(params)
+		var numTxns int
+		for _, txns := range testTxBlocks[n] {
+			numTxns += len(txns)
+		}
+		return map[string]interface{}{
+			"header": map[string]interface{}{
+				"BlockNum": strconv.FormatUint(n, 10),
+				// a block every 30s, a DS epoch every 2 blocks
+				"Timestamp":      strconv.FormatUint(testTxBlockTimestamp+n*30e6, 10),
+				"DSBlockNum":     strconv.FormatUint(n/2, 10),
+				"NumTxns":        numTxns,
+				"NumMicroBlocks": 2,
+				"GasUsed":        strconv.Itoa(numTxns * 50),
+				"Rewards":        strconv.Itoa(numTxns * 1000),
+			},
+			"body": map[string]interface{}{
+				"MicroBlockInfos": []map[string]interface{}{{"MicroBlockShardId": 0}, {"MicroBlockShardId": 1}},
+			},
+		}, nil
+	})
+	server.SetHandler("GetTransactionsForTxBlock", func(call int, params json.RawMessage) (interface{}, error) {
+		return testTxBlocks[blockNum(params)], nil
+	})
+	return server
+}
+
+func TestTxBlockFollower(t *testing.T) {
+	assert := asserting.New(t)
+	server := newTestTxBlockServer()
+	defer server.Close()
+	server.SetResults("GetLatestTxBlock",
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "100"}},
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "103"}},
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "103"}},
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "200"}},
+	)
+
+	constants := testConstants(testOptions(t, "--api", server.URL, "--block-follow-max", "5"), Lookup)
+	f := NewTxBlockFollower(constants)
+	assert.Equal(0, testutil.CollectAndCount(f))
+
+	f.follower.pollOnce()
+	f.follower.pollOnce()
+	// no new block
+	f.follower.pollOnce()
+	assert.Equal(4, server.Calls("GetTxBlock"))
+	// blocks without transactions are not asked for them
+	assert.Equal(2, server.Calls("GetTransactionsForTxBlock"))
+
+	f.mu.Lock()
+	assert.Equal(uint64(4), f.transactions.count)
+	assert.Equal(8.0, f.transactions.sum)
+	assert.Equal(400.0, f.gasUsed.sum)
+	assert.Equal(8.0, f.microBlocks.sum)
+	f.mu.Unlock()
+	expected := fmt.Sprintf(`
+# HELP tx_block_follower_height The latest TX block walked by the block follower
+# TYPE tx_block_follower_height gauge
+tx_block_follower_height%s 103
+# HELP tx_block_rewards_total Rewards of TX blocks in Qa
+# TYPE tx_block_rewards_total counter
+tx_block_rewards_total%s 8000
+# HELP tx_block_shard_transactions_total Transactions in micro blocks of a shard, the DS committee is the shard numbered after the last one
+# TYPE tx_block_shard_transactions_total counter
+tx_block_shard_transactions_total%s 7
+tx_block_shard_transactions_total%s 1
+`, labelText(constants), labelText(constants),
+		labelText(constants, "shard_id", "0"), labelText(constants, "shard_id", "1"))
+	assert.NoError(testutil.CollectAndCompare(f, strings.NewReader(expected),
+		"tx_block_follower_height", "tx_block_rewards_total", "tx_block_shard_transactions_total"))
+
+	// falling behind
+	f.follower.pollOnce()
+	height, _ := f.follower.height()
+	assert.Equal(uint64(200), height)
+	assert.Equal(uint64(92), f.follower.skippedBlocks())
+	assert.Equal(9, server.Calls("GetTxBlock"))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// intervals across the skipped blocks are not observed
+	assert.Equal(uint64(7), f.intervals.count)
+	assert.Equal(210.0, f.intervals.sum)
+	// only the DS epoch of blocks 198 and 199 is walked from its first block to the next epoch
+	assert.Equal(uint64(1), f.epochBlocks.count)
+	assert.Equal(2.0, f.epochBlocks.sum)
+	assert.Equal(uint64(1), f.epochDurations.count)
+	assert.Equal(60.0, f.epochDurations.sum)
+}
+
+func TestTxBlockFollowerFailure(t *testing.T) {
+	assert := asserting.New(t)
+	server := newTestTxBlockServer()
+	defer server.Close()
+	server.SetResults("GetLatestTxBlock",
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "100"}},
+		map[string]interface{}{"header": map[string]interface{}{"BlockNum": "103"}},
+	)
+	constants := testConstants(testOptions(t, "--api", server.URL), Lookup)
+	f := NewTxBlockFollower(constants)
+	f.follower.pollOnce()
+
+	// blocks from 101 are walked again once its transactions are available
+	server.SetHandler("GetTransactionsForTxBlock", func(call int, params json.RawMessage) (interface{}, error) {
+		if call == 1 {
+			return nil, jsonrpc.InternalError
+		}
+		var nums []string
+		_ = json.Unmarshal(params, &nums)
+		n, _ := strconv.ParseUint(nums[0], 10, 64)
+		return testTxBlocks[n], nil
+	})
+	f.follower.pollOnce()
+	height, _ := f.follower.height()
+	assert.Equal(uint64(100), height)
+	f.follower.pollOnce()
+	height, _ = f.follower.height()
+	assert.Equal(uint64(103), height)
+	f.mu.Lock()
+	assert.Equal(uint64(4), f.transactions.count)
+	assert.Equal(8.0, f.transactions.sum)
+	f.mu.Unlock()
+}
@@ -0,0 +1,177 @@
+// Package gateway exposes mutating admin operations of the local zilliqa node over authenticated HTTP.
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// outcomes of an admin operation
+const (
+	OutcomeOK           = "ok"
+	OutcomeError        = "error"
+	OutcomeUnauthorized = "unauthorized"
+	OutcomeBadRequest   = "bad_request"
+)
+
+type operation struct {
+	// an ip address is required as the only param
+	needIP bool
+	call   func(ctx context.Context, cli *adminclient.Client, ip string) (bool, error)
+}
+
+var operations = map[adminclient.MethodName]operation{
+	adminclient.AddToBlacklistExclusion: {needIP: true, call: func(ctx context.Context, cli *adminclient.Client, ip string) (bool, error) {
+		return cli.AddToBlacklistExclusionContext(ctx, ip)
+	}},
+	adminclient.RemoveFromBlacklistExclusion: {needIP: true, call: func(ctx context.Context, cli *adminclient.Client, ip string) (bool, error) {
+		return cli.RemoveFromBlacklistExclusionContext(ctx, ip)
+	}},
+	adminclient.ToggleSendSCCallsToDS: {call: func(ctx context.Context, cli *adminclient.Client, _ string) (bool, error) {
+		return cli.ToggleSendSCCallsToDSContext(ctx)
+	}},
+	adminclient.DisablePoW: {call: func(ctx context.Context, cli *adminclient.Client, _ string) (bool, error) {
+		return cli.DisablePoWContext(ctx)
+	}},
+	adminclient.ToggleDisableTxns: {call: func(ctx context.Context, cli *adminclient.Client, _ string) (bool, error) {
+		return cli.ToggleDisableTxnsContext(ctx)
+	}},
+}
+
+// Result is the response body of an admin operation
+type Result struct {
+	Method string `json:"method"`
+	Result *bool  `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AdminGateway proxies admin operations to the admin api of the local node
+type AdminGateway struct {
+	client  *adminclient.Client
+	tokens  map[string]string // caller by token
+	timeout time.Duration
+	audit   log.FieldLogger
+
+	operationsTotal *prometheus.CounterVec
+}
+
+// NewAdminGateway returns a gateway calling client, authenticating requests by tokens (caller by token)
+// and writing audit trail to audit.
+func NewAdminGateway(client *adminclient.Client, tokens map[string]string, timeout time.Duration, audit log.FieldLogger) *AdminGateway {
+	if audit == nil {
+		audit = log.StandardLogger()
+	}
+	return &AdminGateway{
+		client:  client,
+		tokens:  tokens,
+		timeout: timeout,
+		audit:   audit.WithField("component", "admin_gateway"),
+		operationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "admin_operations_total",
+			Help: "Admin operations requested through the admin gateway of the exporter",
+		}, []string{"method", "outcome"}),
+	}
+}
+
+// Register mounts operations on POST /admin/{method}
+func (g *AdminGateway) Register(router *mux.Router) {
+	router.HandleFunc("/admin/{method}", g.ServeOperation).Methods(http.MethodPost)
+}
+
+func (g *AdminGateway) Describe(ch chan<- *prometheus.Desc) {
+	g.operationsTotal.Describe(ch)
+}
+
+func (g *AdminGateway) Collect(ch chan<- prometheus.Metric) {
+	g.operationsTotal.Collect(ch)
+}
+
+// authenticate returns the caller of the bearer token of r
+func (g *AdminGateway) authenticate(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	given := []byte(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+	for token, caller := range g.tokens {
+		if subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+			return caller, true
+		}
+	}
+	return "", false
+}
+
+func (g *AdminGateway) ServeOperation(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	method := adminclient.MethodName(mux.Vars(r)["method"])
+	ip := r.FormValue("ip")
+	entry := g.audit.WithFields(log.Fields{
+		"remote_addr": r.RemoteAddr,
+		"method":      string(method),
+	})
+	if ip != "" {
+		entry = entry.WithField("ip", ip)
+	}
+
+	caller, ok := g.authenticate(r)
+	if !ok {
+		entry.WithField("outcome", OutcomeUnauthorized).Warn("admin operation rejected")
+		g.respond(w, http.StatusUnauthorized, method, OutcomeUnauthorized, Result{Method: string(method), Error: "invalid or missing bearer token"})
+		return
+	}
+	entry = entry.WithField("caller", caller)
+
+	op, ok := operations[method]
+	if !ok {
+		entry.WithField("outcome", OutcomeBadRequest).Warn("admin operation rejected")
+		g.respond(w, http.StatusNotFound, method, OutcomeBadRequest, Result{Method: string(method), Error: "unknown admin operation"})
+		return
+	}
+	if op.needIP && net.ParseIP(ip) == nil {
+		entry.WithField("outcome", OutcomeBadRequest).Warn("admin operation rejected")
+		g.respond(w, http.StatusBadRequest, method, OutcomeBadRequest, Result{Method: string(method), Error: "param ip should be an ip address"})
+		return
+	}
+	if g.client == nil {
+		entry.WithField("outcome", OutcomeError).Error("admin operation failed: admin client not initialized")
+		g.respond(w, http.StatusServiceUnavailable, method, OutcomeError, Result{Method: string(method), Error: "admin client not initialized"})
+		return
+	}
+
+	ctx := r.Context()
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+	result, err := op.call(ctx, g.client, ip)
+	entry = entry.WithField("duration", time.Since(start).String())
+	if err != nil {
+		entry.WithError(err).WithField("outcome", OutcomeError).Error("admin operation failed")
+		g.respond(w, http.StatusBadGateway, method, OutcomeError, Result{Method: string(method), Error: errors.Cause(err).Error()})
+		return
+	}
+	entry.WithField("outcome", OutcomeOK).WithField("result", result).Info("admin operation performed")
+	g.respond(w, http.StatusOK, method, OutcomeOK, Result{Method: string(method), Result: &result})
+}
+
+func (g *AdminGateway) respond(w http.ResponseWriter, status int, method adminclient.MethodName, outcome string, result Result) {
+	if _, known := operations[method]; !known {
+		// keep cardinality bounded
+		method = "unknown"
+	}
+	g.operationsTotal.WithLabelValues(string(method), outcome).Inc()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
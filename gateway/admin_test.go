@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/adminclient"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAdminGateway(t *testing.T) {
+	assert := asserting.New(t)
+	node := jsonrpctest.NewTCPServer()
+	defer node.Close()
+	node.SetResult(string(adminclient.AddToBlacklistExclusion), true)
+	node.SetError(string(adminclient.DisablePoW), jsonrpc.RPCError{Code: -32600, Message: "Not a DS node"})
+
+	audit, hook := test.NewNullLogger()
+	cli := adminclient.New(node.Addr(), time.Second, jsonrpc.DefaultTCPOptions())
+	defer cli.Close()
+	gw := NewAdminGateway(cli, map[string]string{"secret": "alice"}, time.Second, audit)
+	router := mux.NewRouter()
+	gw.Register(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(path, token string) (int, Result) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, nil)
+		assert.NoError(err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(err)
+		defer resp.Body.Close()
+		var result Result
+		assert.NoError(json.NewDecoder(resp.Body).Decode(&result))
+		return resp.StatusCode, result
+	}
+
+	status, _ := do("/admin/AddToBlacklistExclusion?ip=10.0.0.1", "wrong")
+	assert.Equal(http.StatusUnauthorized, status)
+	assert.Equal(0, node.Calls(string(adminclient.AddToBlacklistExclusion)))
+
+	status, _ = do("/admin/AddToBlacklistExclusion?ip=not-an-ip", "secret")
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, _ = do("/admin/GetNodeType", "secret")
+	assert.Equal(http.StatusNotFound, status)
+
+	status, result := do("/admin/AddToBlacklistExclusion?ip=10.0.0.1", "secret")
+	assert.Equal(http.StatusOK, status)
+	assert.True(*result.Result)
+	assert.Equal(1, node.Calls(string(adminclient.AddToBlacklistExclusion)))
+	entry := hook.LastEntry()
+	assert.Equal(logrus.InfoLevel, entry.Level)
+	assert.Equal("alice", entry.Data["caller"])
+	assert.Equal("10.0.0.1", entry.Data["ip"])
+	assert.Equal(OutcomeOK, entry.Data["outcome"])
+
+	status, result = do("/admin/DisablePoW", "secret")
+	assert.Equal(http.StatusBadGateway, status)
+	assert.Contains(result.Error, "Not a DS node")
+	assert.Equal(OutcomeError, hook.LastEntry().Data["outcome"])
+
+	assert.Equal(float64(1), testutil.ToFloat64(gw.operationsTotal.WithLabelValues("AddToBlacklistExclusion", OutcomeOK)))
+	assert.Equal(float64(1), testutil.ToFloat64(gw.operationsTotal.WithLabelValues("AddToBlacklistExclusion", OutcomeUnauthorized)))
+	assert.Equal(float64(1), testutil.ToFloat64(gw.operationsTotal.WithLabelValues("DisablePoW", OutcomeError)))
+	assert.Equal(float64(1), testutil.ToFloat64(gw.operationsTotal.WithLabelValues("unknown", OutcomeBadRequest)))
+	assert.Len(hook.AllEntries(), 5)
+}
+
+func TestOptionsTokens(t *testing.T) {
+	assert := asserting.New(t)
+	f, err := ioutil.TempFile("", "tokens")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("# on-call\nalice: secret1\n\nbob:secret2\n")
+	_ = f.Close()
+
+	tokens, err := Options{TokensFile: f.Name()}.Tokens()
+	assert.NoError(err)
+	assert.Equal(map[string]string{"secret1": "alice", "secret2": "bob"}, tokens)
+
+	_, err = Options{}.Tokens()
+	assert.Error(err)
+
+	for _, duplicated := range []string{"alice:secret1\nbob:secret1\n", "alice:secret1\nalice:secret2\n"} {
+		assert.NoError(ioutil.WriteFile(f.Name(), []byte(duplicated), 0600))
+		_, err = Options{TokensFile: f.Name()}.Tokens()
+		assert.Error(err, duplicated)
+	}
+}
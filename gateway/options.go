@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+)
+
+type Options struct {
+	Enabled    bool
+	TokensFile string
+	AuditLog   string
+}
+
+func (o *Options) BindFlags(set *pflag.FlagSet) {
+	set.BoolVar(&o.Enabled, "admin-gateway", false, "serve admin operations on /admin/, requires --admin-gateway-tokens")
+	set.StringVar(&o.TokensFile, "admin-gateway-tokens", "", `file of admin gateway tokens, one "caller:token" per line`)
+	set.StringVar(&o.AuditLog, "admin-gateway-audit-log", "", "file the audit trail of admin operations is appended to, stderr if empty")
+}
+
+func (o Options) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"AdminGateway":         o.Enabled,
+		"AdminGatewayTokens":   o.TokensFile,
+		"AdminGatewayAuditLog": o.AuditLog,
+	}
+}
+
+// Tokens reads TokensFile and returns callers by token.
+// A token or a caller listed twice is an error, as the audit trail could not tell who called
+func (o Options) Tokens() (map[string]string, error) {
+	if o.TokensFile == "" {
+		return nil, errors.New("admin gateway tokens file not set")
+	}
+	f, err := os.Open(o.TokensFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to open admin gateway tokens file")
+	}
+	defer f.Close()
+	tokens := make(map[string]string)
+	callers := make(map[string]int) // line by caller
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		split := strings.SplitN(text, ":", 2)
+		if len(split) != 2 || strings.TrimSpace(split[0]) == "" || strings.TrimSpace(split[1]) == "" {
+			return nil, errors.Errorf("invalid admin gateway token at line %d, should be in the form of \"caller:token\"", line)
+		}
+		caller, token := strings.TrimSpace(split[0]), strings.TrimSpace(split[1])
+		if _, ok := tokens[token]; ok {
+			return nil, errors.Errorf("duplicated admin gateway token at line %d", line)
+		}
+		if first, ok := callers[caller]; ok {
+			return nil, errors.Errorf("duplicated admin gateway caller %q at line %d, first at line %d", caller, line, first)
+		}
+		tokens[token] = caller
+		callers[caller] = line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "fail to read admin gateway tokens file")
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("no token in admin gateway tokens file")
+	}
+	return tokens, nil
+}
+
+// AuditLogger returns the logger of audit trail, which writes json lines to AuditLog if set
+func (o Options) AuditLogger() (log.FieldLogger, error) {
+	if o.AuditLog == "" {
+		return log.StandardLogger(), nil
+	}
+	f, err := os.OpenFile(o.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to open admin gateway audit log")
+	}
+	logger := log.New()
+	logger.SetOutput(f)
+	logger.SetFormatter(&log.JSONFormatter{})
+	return logger, nil
+}
@@ -24,4 +24,5 @@ require (
 	github.com/urfave/negroni v1.0.0
 	golang.org/x/sys v0.0.0-20200929083018-4d22bbb62b3c // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
+	gopkg.in/yaml.v2 v2.3.0
 )
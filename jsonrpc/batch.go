@@ -0,0 +1,103 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+)
+
+var ErrMissingResponse = errors.New("no response in batch")
+
+// BatchResponse holds responses of a batch call, matched to the requests by id
+type BatchResponse struct {
+	requests  []*Request
+	responses map[int64]*Response
+	unmatched []*Response
+}
+
+func NewBatchResponse(requests []*Request, responses []*Response) *BatchResponse {
+	b := &BatchResponse{
+		requests:  requests,
+		responses: make(map[int64]*Response, len(responses)),
+	}
+	wanted := make(map[int64]bool, len(requests))
+	for _, r := range requests {
+		wanted[r.id] = true
+	}
+	for _, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		if _, dup := b.responses[resp.Id]; dup || !wanted[resp.Id] {
+			b.unmatched = append(b.unmatched, resp)
+			continue
+		}
+		b.responses[resp.Id] = resp
+	}
+	return b
+}
+
+// Len returns the count of requests of the batch
+func (b *BatchResponse) Len() int {
+	return len(b.requests)
+}
+
+func (b *BatchResponse) Requests() []*Request {
+	return b.requests
+}
+
+// Response returns the response of request, nil if missing
+func (b *BatchResponse) Response(request *Request) *Response {
+	return b.responses[request.id]
+}
+
+// Get returns the response of request, with an error if the response is missing or is a RPC error
+func (b *BatchResponse) Get(request *Request) (*Response, error) {
+	resp := b.Response(request)
+	if resp == nil {
+		return nil, errors.Wrapf(ErrMissingResponse, "method %s id %d", request.Method, request.id)
+	}
+	return resp, resp.Err()
+}
+
+// Err returns the error of request, nil if it succeeded
+func (b *BatchResponse) Err(request *Request) error {
+	_, err := b.Get(request)
+	return err
+}
+
+// Errors returns errors of failed requests
+func (b *BatchResponse) Errors() map[*Request]error {
+	errs := make(map[*Request]error)
+	for _, r := range b.requests {
+		if err := b.Err(r); err != nil {
+			errs[r] = err
+		}
+	}
+	return errs
+}
+
+// Unmatched returns responses with unknown or duplicated ids
+func (b *BatchResponse) Unmatched() []*Response {
+	return b.unmatched
+}
+
+func parseBatchResponse(raw []byte, requests []*Request) (*BatchResponse, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		// servers may answer a whole batch with one error, e.g. on parse error
+		resp, err := parseResponse(raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "jsonrpc response parse error")
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return nil, errors.New("jsonrpc response parse error: expect array for batch request")
+	}
+	var resps []*Response
+	if err := json.Unmarshal(raw, &resps); err != nil {
+		return nil, errors.Wrap(err, "jsonrpc response parse error")
+	}
+	return NewBatchResponse(requests, resps), nil
+}
@@ -0,0 +1,54 @@
+package jsonrpc
+
+import (
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseBatchResponse(t *testing.T) {
+	assert := asserting.New(t)
+	reqs := []*Request{
+		{id: 1, Method: "GetCurrentMiniEpoch"},
+		{id: 2, Method: "GetCurrentDSEpoch"},
+		{id: 3, Method: "GetNodeState"},
+		{id: 4, Method: "GetPrevDifficulty"},
+	}
+	// out of order, string id, rpc error, missing id 4, unknown id 9 and duplicated id 1
+	raw := []byte(`[
+		{"id":2,"jsonrpc":"2.0","result":"19"},
+		{"id":"1","jsonrpc":"2.0","result":"1934"},
+		{"id":3,"jsonrpc":"2.0","error":{"code":-32601,"message":"METHOD_NOT_FOUND: The method being requested is not available on this server"}},
+		{"id":9,"jsonrpc":"2.0","result":"9"},
+		{"id":1,"jsonrpc":"2.0","result":"1"}
+	]`)
+	batch, err := parseBatchResponse(raw, reqs)
+	assert.NoError(err)
+	assert.Equal(4, batch.Len())
+
+	resp, err := batch.Get(reqs[0])
+	assert.NoError(err)
+	n, _ := resp.GetInt64()
+	assert.Equal(int64(1934), n)
+
+	resp, err = batch.Get(reqs[1])
+	assert.NoError(err)
+	n, _ = resp.GetInt64()
+	assert.Equal(int64(19), n)
+
+	_, err = batch.Get(reqs[2])
+	assert.True(errors.Is(err, MethodNotFound))
+
+	_, err = batch.Get(reqs[3])
+	assert.True(errors.Is(err, ErrMissingResponse))
+
+	assert.Len(batch.Unmatched(), 2)
+	assert.Len(batch.Errors(), 2)
+
+	// whole batch rejected
+	_, err = parseBatchResponse([]byte(`{"id":null,"jsonrpc":"2.0","error":{"code":-32700,"message":"PARSE_ERROR"}}`), reqs)
+	assert.True(errors.Is(err, ParserError))
+
+	_, err = parseBatchResponse([]byte(`[{"id":1,`), reqs)
+	assert.Error(err)
+}
@@ -0,0 +1,134 @@
+package jsonrpc
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+var BreakerStates = []BreakerState{BreakerClosed, BreakerHalfOpen, BreakerOpen}
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker stops calls to an endpoint after consecutive failures.
+// After Cooldown, one trial call is let through (half-open), which closes the breaker on success.
+type CircuitBreaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool // a trial call is in flight while half-open
+	now      func() time.Time
+}
+
+// NewCircuitBreaker returns a breaker opening after threshold consecutive failures, 0 disables it
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Name: name, Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen if the call should not be made
+func (b *CircuitBreaker) Allow() error {
+	if b.Threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+		b.trial = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	if b.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+	b.setState(BreakerClosed)
+}
+
+func (b *CircuitBreaker) Failure() {
+	if b.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// Abort ends a call aborted by the caller before an answer, which counts as neither a success nor a failure,
+// a trial call is let through again while half-open
+func (b *CircuitBreaker) Abort() {
+	if b.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *CircuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+	entry := log.WithField("endpoint", b.Name).WithField("from", b.state.String()).WithField("to", state.String())
+	if state == BreakerOpen {
+		entry.Warnf("circuit breaker open after %d failures, backing off for %s", b.failures, b.Cooldown)
+	} else {
+		entry.Info("circuit breaker state changed")
+	}
+	b.state = state
+}
@@ -1,15 +1,15 @@
 package jsonrpc
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"net"
+	"strings"
 	"sync/atomic"
+	"time"
 )
 
 type Conn net.Conn
@@ -18,27 +18,86 @@ type Client interface {
 	Address() string
 	Call(request *Request) (*Response, error)
 	CallContext(ctx context.Context, request *Request) (*Response, error)
-	CallBatch(requests ...*Request) ([]*Response, error)
-	CallBatchContext(ctx context.Context, requests ...*Request) ([]*Response, error)
+	CallBatch(requests ...*Request) (*BatchResponse, error)
+	CallBatchContext(ctx context.Context, requests ...*Request) (*BatchResponse, error)
 }
 
+type TCPOptions struct {
+	// max idle connections kept for reuse, 0 disables connection reuse
+	PoolMaxIdle int
+	// idle connections unused longer than this are closed
+	PoolIdleTimeout time.Duration
+	// connections older than this are closed, 0 means no limit
+	PoolMaxLifetime time.Duration
+	// connect with TLS if not nil
+	TLSConfig *tls.Config
+	// max bytes of a single response, 0 means no limit
+	MaxResponseSize int64
+}
+
+func DefaultTCPOptions() TCPOptions {
+	return TCPOptions{
+		PoolMaxIdle:     2,
+		PoolIdleTimeout: 30 * time.Second,
+		MaxResponseSize: DefaultMaxResponseSize,
+	}
+}
+
+// SplitAddress returns the network and address to dial of addr,
+// which is host:port, tcp://host:port or unix:///path/to/socket
+func SplitAddress(addr string) (network, address string) {
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		return "unix", strings.TrimPrefix(addr, "unix://")
+	case strings.HasPrefix(addr, "tcp://"):
+		return "tcp", strings.TrimPrefix(addr, "tcp://")
+	}
+	return "tcp", addr
+}
+
+// TCPClient sends JsonRPC requests over TCP or unix domain socket
 type TCPClient struct {
 	addr      string
 	closeOnce bool
 	counter   int64
+	pool      *ConnectionPool
+	maxSize   int64
 	// TLS
 	tls       bool
 	tlsConfig *tls.Config
 }
 
 func NewTCPClient(addr string) *TCPClient {
-	return &TCPClient{addr: addr}
+	return NewTCPClientWithOptions(addr, DefaultTCPOptions())
+}
+
+func NewTCPClientWithOptions(addr string, opts TCPOptions) *TCPClient {
+	c := &TCPClient{addr: addr, maxSize: opts.MaxResponseSize, tls: opts.TLSConfig != nil, tlsConfig: opts.TLSConfig}
+	c.pool = NewConnPool(c.dialContext, opts.PoolMaxIdle, opts.PoolIdleTimeout)
+	c.pool.MaxLifetime = opts.PoolMaxLifetime
+	return c
 }
 
-func (c TCPClient) Address() string {
+func (c *TCPClient) Address() string {
 	return c.addr
 }
 
+// PoolStats returns the statistics of the connection pool
+func (c *TCPClient) PoolStats() PoolStats {
+	if c.pool == nil {
+		return PoolStats{}
+	}
+	return c.pool.Stats()
+}
+
+// Close closes idle pooled connections
+func (c *TCPClient) Close() error {
+	if c.pool == nil {
+		return nil
+	}
+	return c.pool.Close()
+}
+
 func (c *TCPClient) getId() int64 {
 	return atomic.AddInt64(&c.counter, 1)
 }
@@ -55,77 +114,110 @@ func (c *TCPClient) dial() (net.Conn, error) {
 
 func (c *TCPClient) dialContext(ctx context.Context) (net.Conn, error) {
 	var d net.Dialer
-	if c.tls {
-		ch := make(chan net.Conn)
-		errCh := make(chan error)
-		go func() {
-			conn, err := tls.Dial("tcp", c.addr, c.tlsConfig)
-			if err != nil {
-				errCh <- err
-				if conn != nil {
-					_ = conn.Close()
-				}
-				return
-			}
-			ch <- conn
-		}()
+	network, addr := SplitAddress(c.addr)
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil || !c.tls {
+		return conn, err
+	}
 
-		select {
-		case conn := <-ch:
-			return conn, nil
-		case err := <-errCh:
+	cfg := c.tlsConfig
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if network == "unix" {
+			host = "localhost"
+		}
+		cfg.ServerName = host
+	}
+	tlsConn := tls.Client(conn, cfg)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tlsConn.Handshake()
+	}()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "tls handshake fail")
+		}
+		return tlsConn, nil
+	case <-ctx.Done():
+		// interrupts the handshake
+		_ = conn.Close()
+		return nil, ctx.Err()
+	}
+}
+
+func (c *TCPClient) getConn(ctx context.Context) (*PoolConn, error) {
+	if c.pool == nil {
+		conn, err := c.dialContext(ctx)
+		if err != nil {
 			return nil, err
-		case <-ctx.Done():
-			return nil, ctx.Err()
 		}
+		return newPoolConn(conn, nil), nil
 	}
-	return d.DialContext(ctx, "tcp", c.addr)
+	return c.pool.Get(ctx)
 }
 
 func (c *TCPClient) getRawResp(ctx context.Context, data []byte) ([]byte, error) {
-	conn, err := c.dialContext(ctx)
+	conn, err := c.getConn(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "fail to connect to server")
 	}
-	defer conn.Close()
+	defer conn.Release()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.MarkBroken()
+		return nil, errors.Wrap(err, "fail to set connection deadline")
+	}
+	// unblock read and write on cancellation
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
 	log.WithField("addr", c.addr).Debugf("sending request %s", string(data))
 	_, err = conn.Write(data)
 	if err != nil {
-		return nil, errors.Wrap(err, "fail to send data to server")
+		conn.MarkBroken()
+		return nil, c.connError(ctx, errors.Wrap(err, "fail to send data to server"))
 	}
-	//_, err = conn.Write([]byte("\n"))
-	buffer := new(bytes.Buffer)
-	doneCh := make(chan struct{})
-	errCh := make(chan error)
-	go func() {
-		log.Debug("reading response")
-		connBuf := bufio.NewReader(conn)
-		for i := 0; i < 5; i++ {
-			buf, err := connBuf.ReadBytes('\n')
-			if len(buf) > 0 {
-				buffer.Write(buf)
-				break
-			}
-			if err != nil {
-				errCh <- errors.Wrap(err, "fail to get response")
-			}
+
+	log.Debug("reading response")
+	buf, err := conn.readResponse(c.maxSize)
+	if err != nil {
+		// framing is lost, connection can not be reused
+		conn.MarkBroken()
+		return nil, c.connError(ctx, errors.Wrap(err, "fail to get response"))
+	}
+	log.WithField("addr", c.addr).WithField("resp", string(buf)).Debug("got response")
+	if ctx.Err() == nil {
+		if err := conn.SetDeadline(time.Time{}); err != nil {
+			conn.MarkBroken()
 		}
-		log.WithField("addr", c.addr).WithField("resp", buffer.String()).Debug("got response")
-		close(doneCh)
-	}()
-	select {
-	case err := <-errCh:
-		log.WithField("addr", c.addr).WithError(err).Error("connection Error")
-		return nil, err
-	case <-ctx.Done():
+	}
+	return buf, nil
+}
+
+func (c *TCPClient) connError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
 		log.WithField("addr", c.addr).Error("connection timeout or canceled")
-		return nil, ctx.Err()
-	case <-doneCh:
+		return ctx.Err()
 	}
-	<-doneCh
-	close(errCh)
-	res := buffer.Bytes()
-	return res, nil
+	log.WithField("addr", c.addr).WithError(err).Error("connection Error")
+	return err
 }
 
 func (*TCPClient) getBatchPayload(rq ...*Request) ([]byte, error) {
@@ -154,6 +246,7 @@ func (c *TCPClient) Call(request *Request) (*Response, error) {
 }
 
 func (c *TCPClient) CallContext(ctx context.Context, request *Request) (*Response, error) {
+	c.setReqId(request)
 	payload, err := c.getPayload(request)
 	if err != nil {
 		return nil, err
@@ -165,11 +258,12 @@ func (c *TCPClient) CallContext(ctx context.Context, request *Request) (*Respons
 	return parseResponse(rawResp)
 }
 
-func (c *TCPClient) CallBatch(requests ...*Request) ([]*Response, error) {
+func (c *TCPClient) CallBatch(requests ...*Request) (*BatchResponse, error) {
 	return c.CallBatchContext(context.Background(), requests...)
 }
 
-func (c *TCPClient) CallBatchContext(ctx context.Context, requests ...*Request) ([]*Response, error) {
+func (c *TCPClient) CallBatchContext(ctx context.Context, requests ...*Request) (*BatchResponse, error) {
+	c.setReqId(requests...)
 	payload, err := c.getBatchPayload(requests...)
 	if err != nil {
 		return nil, err
@@ -178,5 +272,5 @@ func (c *TCPClient) CallBatchContext(ctx context.Context, requests ...*Request)
 	if err != nil {
 		return nil, err
 	}
-	return parseBatchResponse(rawResp)
+	return parseBatchResponse(rawResp, requests)
 }
@@ -2,21 +2,29 @@ package jsonrpc
 
 import (
 	"context"
+	"crypto/tls"
 	asserting "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestTCPClient(t *testing.T) {
 	assert := asserting.New(t)
+	cert := newTestCert(t, "localhost", nil, true)
+	l := tlsLineServer(t, &tls.Config{Certificates: []tls.Certificate{cert.tlsCert}}, `{}`)
+	defer l.Close()
 	cli := TCPClient{
-		addr:      "dev.zilliqa.com:443",
+		addr:      l.Addr().String(),
 		closeOnce: false,
 		tls:       true,
-		tlsConfig: nil,
+		tlsConfig: &tls.Config{InsecureSkipVerify: true},
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	go func() { cancel() }()
+	cancel()
 	conn, err := cli.dialContext(ctx)
 	assert.Error(err)
 	assert.Nil(conn)
@@ -24,3 +32,38 @@ func TestTCPClient(t *testing.T) {
 	assert.NoError(err)
 	assert.NotNil(conn)
 }
+
+func TestSplitAddress(t *testing.T) {
+	assert := asserting.New(t)
+	for addr, want := range map[string][2]string{
+		"127.0.0.1:4301":                  {"tcp", "127.0.0.1:4301"},
+		"tcp://127.0.0.1:4301":            {"tcp", "127.0.0.1:4301"},
+		"unix:///run/zilliqa/status.sock": {"unix", "/run/zilliqa/status.sock"},
+	} {
+		network, address := SplitAddress(addr)
+		assert.Equal(want, [2]string{network, address}, addr)
+	}
+}
+
+func TestTCPClientUnixSocket(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "jsonrpc")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "status.sock")
+	l, err := net.Listen("unix", path)
+	assert.NoError(err)
+	go serveLines(l, `{"id":1,"jsonrpc":"2.0","result":"1934"}`, false)
+	defer l.Close()
+
+	cli := NewTCPClient("unix://" + path)
+	defer cli.Close()
+	for i := 0; i < 2; i++ {
+		resp, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+		assert.NoError(err)
+		n, err := resp.GetInt64()
+		assert.NoError(err)
+		assert.Equal(int64(1934), n)
+	}
+	assert.Equal(uint64(1), cli.PoolStats().Hits)
+}
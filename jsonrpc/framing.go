@@ -0,0 +1,85 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultMaxResponseSize limits the bytes read for a single response
+const DefaultMaxResponseSize = 8 << 20
+
+var (
+	ErrResponseTooLarge  = errors.New("response exceeds max size")
+	ErrResponseTruncated = errors.New("response truncated")
+	ErrResponseMalformed = errors.New("response is not valid json")
+	ErrConnectionClosed  = errors.New("connection closed before response")
+)
+
+// limitedReader fails with ErrResponseTooLarge after max bytes were read since the last reset
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *limitedReader) reset(max int64) {
+	l.max = max
+	l.read = 0
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.max > 0 {
+		remain := l.max - l.read
+		if remain <= 0 {
+			return 0, ErrResponseTooLarge
+		}
+		if int64(len(p)) > remain {
+			p = p[:remain]
+		}
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// readResponse decodes exactly one json value from the connection, whatever the framing is
+func (c *PoolConn) readResponse(maxSize int64) (json.RawMessage, error) {
+	c.limiter.reset(maxSize)
+	var raw json.RawMessage
+	err := c.decoder.Decode(&raw)
+	if err == nil {
+		return raw, nil
+	}
+	read := c.limiter.read
+	switch {
+	case errors.Is(err, ErrResponseTooLarge):
+		return nil, errors.Wrapf(ErrResponseTooLarge, "more than %d bytes", maxSize)
+	case err == io.EOF:
+		return nil, ErrConnectionClosed
+	case err == io.ErrUnexpectedEOF:
+		return nil, errors.Wrapf(ErrResponseTruncated, "connection closed after %d bytes", read)
+	}
+	if _, ok := err.(*json.SyntaxError); ok {
+		return nil, errors.Wrap(ErrResponseMalformed, err.Error())
+	}
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		return nil, errors.Wrap(ErrResponseMalformed, err.Error())
+	}
+	if read > 0 {
+		// partial response, e.g. deadline exceeded in the middle of a response
+		return nil, errors.Wrapf(ErrResponseTruncated, "%s after %d bytes", err.Error(), read)
+	}
+	return nil, err
+}
+
+// hasBufferedData reports whether data other than whitespaces was received but not consumed
+func (c *PoolConn) hasBufferedData() bool {
+	if c.decoder == nil {
+		return false
+	}
+	buffered, _ := ioutil.ReadAll(c.decoder.Buffered())
+	return len(bytes.TrimSpace(buffered)) > 0
+}
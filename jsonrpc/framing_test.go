@@ -0,0 +1,110 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptServer writes chunks with a small delay in between for every request line received,
+// then closes or half-closes the connection if asked.
+func scriptServer(t *testing.T, chunks []string, closeWrite, closeConn bool) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if _, err := r.ReadBytes('\n'); err != nil {
+						return
+					}
+					for _, chunk := range chunks {
+						if _, err := conn.Write([]byte(chunk)); err != nil {
+							return
+						}
+						time.Sleep(5 * time.Millisecond)
+					}
+					if closeWrite {
+						_ = conn.(*net.TCPConn).CloseWrite()
+					}
+					if closeConn {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func TestTCPClientFraming(t *testing.T) {
+	pretty := "{\n  \"id\": 1,\n  \"jsonrpc\": \"2.0\",\n  \"result\": \"1934\"\n}"
+	cases := []struct {
+		name       string
+		chunks     []string
+		closeWrite bool
+		closeConn  bool
+		maxSize    int64
+		err        error
+	}{
+		{name: "multi-line without delimiter", chunks: []string{pretty}},
+		{name: "partial reads", chunks: []string{`{"id":1,"json`, `rpc":"2.0","res`, `ult":"1934"}`, "\n"}},
+		{name: "half-close after response", chunks: []string{pretty}, closeWrite: true},
+		{name: "truncated", chunks: []string{`{"id":1,"jsonrpc":"2.0","res`}, closeConn: true, err: ErrResponseTruncated},
+		{name: "closed without response", closeConn: true, err: ErrConnectionClosed},
+		{name: "too large", chunks: []string{`{"id":1,"jsonrpc":"2.0","result":"` + strings.Repeat("1", 128) + `"}`}, maxSize: 64, err: ErrResponseTooLarge},
+		{name: "malformed", chunks: []string{"HTTP/1.1 400 Bad Request\r\n\r\n"}, closeConn: true, err: ErrResponseMalformed},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			assert := asserting.New(t)
+			l := scriptServer(t, c.chunks, c.closeWrite, c.closeConn)
+			defer l.Close()
+			opts := DefaultTCPOptions()
+			if c.maxSize > 0 {
+				opts.MaxResponseSize = c.maxSize
+			}
+			cli := NewTCPClientWithOptions(l.Addr().String(), opts)
+			defer cli.Close()
+			resp, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+			if c.err != nil {
+				assert.True(errors.Is(err, c.err), "expect %v, got %v", c.err, err)
+				assert.Equal(0, cli.PoolStats().IdleConns)
+				return
+			}
+			assert.NoError(err)
+			n, err := resp.GetInt64()
+			assert.NoError(err)
+			assert.Equal(int64(1934), n)
+		})
+	}
+}
+
+func TestTCPClientHalfClosedConnEvicted(t *testing.T) {
+	assert := asserting.New(t)
+	l := scriptServer(t, []string{`{"id":1,"jsonrpc":"2.0","result":"1934"}`}, true, false)
+	defer l.Close()
+	cli := NewTCPClient(l.Addr().String())
+	defer cli.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+		assert.NoError(err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(uint64(1), cli.PoolStats().Evictions)
+	assert.Equal(uint64(0), cli.PoolStats().Hits)
+}
@@ -0,0 +1,163 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// HTTPError is returned when server responds with a non 2xx status code
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Endpoint   string
+}
+
+func (e HTTPError) Error() string {
+	return fmt.Sprintf("unexpected http status from %s: %s", e.Endpoint, e.Status)
+}
+
+type HTTPOptions struct {
+	// extra headers sent with every request
+	Headers http.Header
+	// basic auth, used if Username is not empty
+	Username string
+	Password string
+	// endpoints tried in order when the previous one fails
+	Fallbacks []string
+	// underlying http client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+type HTTPClient struct {
+	endpoints []string
+	current   int64 // index of the endpoint last succeeded
+	counter   int64
+	opts      HTTPOptions
+}
+
+func NewHTTPClient(endpoint string, opts HTTPOptions) *HTTPClient {
+	c := &HTTPClient{opts: opts}
+	c.endpoints = append([]string{endpoint}, opts.Fallbacks...)
+	if c.opts.Client == nil {
+		c.opts.Client = http.DefaultClient
+	}
+	return c
+}
+
+// Address returns the endpoint in use
+func (c *HTTPClient) Address() string {
+	return c.endpoints[atomic.LoadInt64(&c.current)]
+}
+
+func (c *HTTPClient) Endpoints() []string {
+	return c.endpoints
+}
+
+func (c *HTTPClient) setReqId(rq ...*Request) {
+	for _, r := range rq {
+		r.id = atomic.AddInt64(&c.counter, 1)
+	}
+}
+
+func (c *HTTPClient) post(ctx context.Context, endpoint string, data []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to build request")
+	}
+	req = req.WithContext(ctx)
+	for k, v := range c.opts.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+	log.WithField("endpoint", endpoint).Debugf("sending request %s", string(data))
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrap(err, "fail to send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil, HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Endpoint: endpoint}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "fail to read response")
+	}
+	log.WithField("endpoint", endpoint).WithField("resp", string(body)).Debug("got response")
+	return body, nil
+}
+
+// getRawResp posts data to the current endpoint, then the others in order until one answers
+func (c *HTTPClient) getRawResp(ctx context.Context, data []byte) ([]byte, error) {
+	start := atomic.LoadInt64(&c.current)
+	var lastErr error
+	for i := 0; i < len(c.endpoints); i++ {
+		idx := (start + int64(i)) % int64(len(c.endpoints))
+		endpoint := c.endpoints[idx]
+		body, err := c.post(ctx, endpoint, data)
+		if err == nil {
+			if idx != start {
+				log.WithField("endpoint", endpoint).Warn("switched to fallback endpoint")
+				atomic.StoreInt64(&c.current, idx)
+			}
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.WithField("endpoint", endpoint).WithError(err).Debug("endpoint failed")
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *HTTPClient) Call(request *Request) (*Response, error) {
+	return c.CallContext(context.Background(), request)
+}
+
+func (c *HTTPClient) CallContext(ctx context.Context, request *Request) (*Response, error) {
+	c.setReqId(request)
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	rawResp, err := c.getRawResp(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(rawResp)
+}
+
+func (c *HTTPClient) CallBatch(requests ...*Request) (*BatchResponse, error) {
+	return c.CallBatchContext(context.Background(), requests...)
+}
+
+func (c *HTTPClient) CallBatchContext(ctx context.Context, requests ...*Request) (*BatchResponse, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("empty requests")
+	}
+	c.setReqId(requests...)
+	payload, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	rawResp, err := c.getRawResp(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchResponse(rawResp, requests)
+}
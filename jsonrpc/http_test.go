@@ -0,0 +1,98 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	asserting "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient(t *testing.T) {
+	assert := asserting.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if body[0] == '[' {
+			var reqs []map[string]interface{}
+			assert.NoError(json.Unmarshal(body, &reqs))
+			var resps []map[string]interface{}
+			for _, req := range reqs {
+				resps = append(resps, map[string]interface{}{"jsonrpc": "2.0", "id": req["id"], "result": req["method"]})
+			}
+			_ = json.NewEncoder(w).Encode(resps)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","result":"1"}`))
+	}))
+	defer server.Close()
+
+	cli := NewHTTPClient(server.URL, HTTPOptions{
+		Headers:  http.Header{"X-Api-Key": []string{"key"}},
+		Username: "user",
+		Password: "pass",
+	})
+	resp, err := cli.Call(NewRequest("GetNetworkId", nil))
+	assert.NoError(err)
+	id, err := resp.GetString()
+	assert.NoError(err)
+	assert.Equal("1", id)
+
+	netIDReq := NewRequest("GetNetworkId", nil)
+	batch, err := cli.CallBatch(NewRequest("GetBlockchainInfo", nil), netIDReq)
+	assert.NoError(err)
+	assert.Equal(2, batch.Len())
+	resp, err = batch.Get(netIDReq)
+	assert.NoError(err)
+	method, err := resp.GetString()
+	assert.NoError(err)
+	assert.Equal("GetNetworkId", method)
+
+	unauthorized := NewHTTPClient(server.URL, HTTPOptions{})
+	_, err = unauthorized.Call(NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	httpErr, ok := err.(HTTPError)
+	assert.True(ok)
+	assert.Equal(http.StatusUnauthorized, httpErr.StatusCode)
+}
+
+func TestHTTPClientFallback(t *testing.T) {
+	assert := asserting.New(t)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","result":"1"}`))
+	}))
+	defer up.Close()
+
+	cli := NewHTTPClient(down.URL, HTTPOptions{Fallbacks: []string{up.URL}})
+	_, err := cli.Call(NewRequest("GetNetworkId", nil))
+	assert.NoError(err)
+	assert.Equal(up.URL, cli.Address())
+}
+
+func TestHTTPClientTimeout(t *testing.T) {
+	assert := asserting.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	cli := NewHTTPClient(server.URL, HTTPOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := cli.CallContext(ctx, NewRequest("GetNetworkId", nil))
+	assert.Equal(context.DeadlineExceeded, err)
+}
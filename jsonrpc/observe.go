@@ -0,0 +1,95 @@
+package jsonrpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// outcomes of a call, besides the error code of a RPCError
+const (
+	OutcomeOK                = "ok"
+	OutcomeTimeout           = "timeout"
+	OutcomeConnectionRefused = "connection_refused"
+	OutcomeError             = "error"
+)
+
+// Observer is notified with the result of every call sent by an ObservedClient
+type Observer interface {
+	ObserveCall(endpoint, method string, duration time.Duration, err error)
+}
+
+// Outcome classifies the error of a call: ok, timeout, connection_refused,
+// the code of a RPCError, http_<status> of a HTTPError, or error for the others.
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeOK
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return OutcomeTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return OutcomeConnectionRefused
+	}
+	switch e := errors.Cause(err).(type) {
+	case RPCError:
+		return strconv.FormatInt(e.Code, 10)
+	case *RPCError:
+		return strconv.FormatInt(e.Code, 10)
+	case HTTPError:
+		return "http_" + strconv.Itoa(e.StatusCode)
+	case net.Error:
+		if e.Timeout() {
+			return OutcomeTimeout
+		}
+	}
+	return OutcomeError
+}
+
+// ObservedClient reports every call to an Observer.
+// Requests of a batch are observed one by one, with the duration of the whole batch.
+type ObservedClient struct {
+	Client
+	observer Observer
+}
+
+func NewObservedClient(cli Client, observer Observer) *ObservedClient {
+	return &ObservedClient{Client: cli, observer: observer}
+}
+
+func (c *ObservedClient) Call(request *Request) (*Response, error) {
+	return c.CallContext(context.Background(), request)
+}
+
+func (c *ObservedClient) CallContext(ctx context.Context, request *Request) (*Response, error) {
+	start := time.Now()
+	resp, err := c.Client.CallContext(ctx, request)
+	observed := err
+	if observed == nil && resp != nil {
+		observed = resp.Err()
+	}
+	c.observer.ObserveCall(c.Address(), request.Method, time.Since(start), observed)
+	return resp, err
+}
+
+func (c *ObservedClient) CallBatch(requests ...*Request) (*BatchResponse, error) {
+	return c.CallBatchContext(context.Background(), requests...)
+}
+
+func (c *ObservedClient) CallBatchContext(ctx context.Context, requests ...*Request) (*BatchResponse, error) {
+	start := time.Now()
+	batch, err := c.Client.CallBatchContext(ctx, requests...)
+	duration := time.Since(start)
+	endpoint := c.Address()
+	for _, req := range requests {
+		observed := err
+		if observed == nil {
+			observed = batch.Err(req)
+		}
+		c.observer.ObserveCall(endpoint, req.Method, duration, observed)
+	}
+	return batch, err
+}
@@ -0,0 +1,65 @@
+package jsonrpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedCall struct {
+	endpoint, method, outcome string
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+func (r *recorder) ObserveCall(endpoint, method string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recordedCall{endpoint, method, Outcome(err)})
+}
+
+func TestOutcome(t *testing.T) {
+	assert := asserting.New(t)
+	assert.Equal(OutcomeOK, Outcome(nil))
+	assert.Equal(OutcomeTimeout, Outcome(errors.Wrap(context.DeadlineExceeded, "timeout: 1s")))
+	assert.Equal("-32601", Outcome(MethodNotFound))
+	assert.Equal("-32601", Outcome(&MethodNotFound))
+	assert.Equal("http_429", Outcome(HTTPError{StatusCode: http.StatusTooManyRequests}))
+	assert.Equal(OutcomeError, Outcome(ErrResponseMalformed))
+}
+
+func TestObservedClient(t *testing.T) {
+	assert := asserting.New(t)
+	l := lineServer(t, `[{"id":1,"jsonrpc":"2.0","result":"1"},{"id":2,"jsonrpc":"2.0","error":{"code":-32601,"message":"METHOD_NOT_FOUND"}}]`, false)
+	defer l.Close()
+	rec := &recorder{}
+	tcp := NewTCPClient(l.Addr().String())
+	defer tcp.Close()
+	cli := NewObservedClient(tcp, rec)
+	_, err := cli.CallBatch(NewRequest("GetCurrentMiniEpoch", nil), NewRequest("GetNodeState", nil))
+	assert.NoError(err)
+	assert.Equal([]recordedCall{
+		{l.Addr().String(), "GetCurrentMiniEpoch", OutcomeOK},
+		{l.Addr().String(), "GetNodeState", "-32601"},
+	}, rec.calls)
+
+	// nothing listens on a just closed port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	addr := closed.Addr().String()
+	_ = closed.Close()
+	refused := NewTCPClient(addr)
+	defer refused.Close()
+	rec = &recorder{}
+	_, err = NewObservedClient(refused, rec).Call(NewRequest("GetNodeType", nil))
+	assert.Error(err)
+	assert.Equal([]recordedCall{{addr, "GetNodeType", OutcomeConnectionRefused}}, rec.calls)
+}
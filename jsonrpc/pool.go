@@ -1,83 +1,233 @@
-// TODO: ref https://learnku.com/articles/41137
-
 package jsonrpc
 
 import (
+	"bufio"
 	"container/list"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// how long a health check waits for a pending EOF or unexpected data on an idle connection
+const healthCheckTimeout = time.Millisecond
+
+var ErrPoolClosed = errors.New("connection pool closed")
+
+// PoolConn is a connection checked out from a ConnectionPool.
+// Release must be called once the caller is done with it.
+type PoolConn struct {
+	net.Conn
+	Reader *bufio.Reader
+
+	limiter *limitedReader
+	decoder *json.Decoder
+
+	pool      *ConnectionPool
+	createdAt time.Time
+	usedAt    time.Time
+	broken    bool
+}
+
+func newPoolConn(conn net.Conn, pool *ConnectionPool) *PoolConn {
+	now := time.Now()
+	limiter := &limitedReader{r: conn}
+	reader := bufio.NewReader(limiter)
+	return &PoolConn{
+		Conn:      conn,
+		Reader:    reader,
+		limiter:   limiter,
+		decoder:   json.NewDecoder(reader),
+		pool:      pool,
+		createdAt: now,
+		usedAt:    now,
+	}
+}
+
+// MarkBroken prevents the connection from being put back to the pool on Release.
+func (c *PoolConn) MarkBroken() {
+	c.broken = true
+}
+
+// Release gives the connection back to the pool, or closes it if it is broken or the pool is full.
+func (c *PoolConn) Release() {
+	if c.pool == nil {
+		_ = c.Conn.Close()
+		return
+	}
+	c.pool.put(c)
+}
+
+type PoolStats struct {
+	Hits       uint64 // idle connection reused
+	Misses     uint64 // no usable idle connection, new one dialed
+	DialErrors uint64
+	Evictions  uint64 // idle connections closed by health check, idle timeout or lifetime
+	IdleConns  int
+	OpenConns  int
+}
+
 type ConnectionPool struct {
-	pool list.List
-	mu   sync.Mutex
-	New  func() (*net.Conn, error)
+	New func(ctx context.Context) (net.Conn, error)
+
+	// max idle connections kept, 0 disables pooling
+	MaxIdle int
+	// idle connections unused longer than IdleTimeout are closed, 0 means no limit
+	IdleTimeout time.Duration
+	// connections older than MaxLifetime are closed, 0 means no limit
+	MaxLifetime time.Duration
+
+	mu     sync.Mutex
+	idle   list.List // *PoolConn, most recently used at the back
+	open   int64
+	closed bool
+
+	hits       uint64
+	misses     uint64
+	dialErrors uint64
+	evictions  uint64
 }
 
-func NewConnPool(newFunc func() (*net.Conn, error)) *ConnectionPool {
-	p := &ConnectionPool{New: newFunc}
-	p.pool.Init()
+func NewConnPool(newFunc func(ctx context.Context) (net.Conn, error), maxIdle int, idleTimeout time.Duration) *ConnectionPool {
+	p := &ConnectionPool{New: newFunc, MaxIdle: maxIdle, IdleTimeout: idleTimeout}
+	p.idle.Init()
 	return p
 }
 
 func (p *ConnectionPool) Len() int {
-	return p.pool.Len()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.idle.Len()
 }
 
-// creat N connection in pool
-func (p *ConnectionPool) Init(n int) (int, []error) {
-	var errs []error
-	var count = 0
-	for i := 0; i < n; i++ {
-		conn, err := p.New()
-		if err != nil {
-			errs = append(errs, err)
+// Get returns a healthy idle connection, or dials a new one
+func (p *ConnectionPool) Get(ctx context.Context) (*PoolConn, error) {
+	for {
+		conn := p.popIdle()
+		if conn == nil {
+			break
+		}
+		if p.expired(conn) || ConnClosed(conn) {
+			p.evict(conn)
+			continue
 		}
-		p.Put(conn)
-		count++
+		atomic.AddUint64(&p.hits, 1)
+		conn.usedAt = time.Now()
+		return conn, nil
 	}
-	return count, errs
-}
 
-func (p *ConnectionPool) Get() *net.Conn {
-	if p.Len() == 0 {
-		return nil
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
 	}
-	defer p.mu.Unlock()
+
+	atomic.AddUint64(&p.misses, 1)
+	raw, err := p.New(ctx)
+	if err != nil {
+		atomic.AddUint64(&p.dialErrors, 1)
+		return nil, err
+	}
+	atomic.AddInt64(&p.open, 1)
+	return newPoolConn(raw, p), nil
+}
+
+func (p *ConnectionPool) popIdle() *PoolConn {
 	p.mu.Lock()
-	elm := p.pool.Front()
+	defer p.mu.Unlock()
+	elm := p.idle.Back()
 	if elm == nil {
 		return nil
 	}
-	p.pool.Remove(elm)
-	conn, _ := elm.Value.(*net.Conn)
-	return conn
+	p.idle.Remove(elm)
+	return elm.Value.(*PoolConn)
 }
 
-func (p *ConnectionPool) GetOrNew() (*net.Conn, error) {
-	conn := p.Get()
-	if conn == nil {
-		return p.New()
+func (p *ConnectionPool) expired(conn *PoolConn) bool {
+	now := time.Now()
+	if p.IdleTimeout > 0 && now.Sub(conn.usedAt) > p.IdleTimeout {
+		return true
 	}
-	return conn, nil
+	if p.MaxLifetime > 0 && now.Sub(conn.createdAt) > p.MaxLifetime {
+		return true
+	}
+	return false
 }
 
-func (p *ConnectionPool) Put(conn *net.Conn) {
-	defer p.mu.Unlock()
+func (p *ConnectionPool) evict(conn *PoolConn) {
+	atomic.AddUint64(&p.evictions, 1)
+	p.closeConn(conn)
+}
+
+func (p *ConnectionPool) closeConn(conn *PoolConn) {
+	atomic.AddInt64(&p.open, -1)
+	_ = conn.Conn.Close()
+}
+
+func (p *ConnectionPool) put(conn *PoolConn) {
+	if conn.broken {
+		p.closeConn(conn)
+		return
+	}
+	conn.usedAt = time.Now()
 	p.mu.Lock()
-	p.pool.PushBack(conn)
-}
-
-func ConnClosed(conn *net.Conn) bool {
-	//one := make([]byte, 1)
-	//conn.SetReadDeadline(time.Now())
-	//if _, err := conn.Read(one); err == io.EOF {
-	//	l.Printf(logger.LevelDebug, "%s detected closed LAN connection", id)
-	//	c.Close()
-	//	c = nil
-	//} else {
-	//	var zero time.Time
-	//	c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
-	//}
-	return false
+	if p.closed || p.idle.Len() >= p.MaxIdle {
+		p.mu.Unlock()
+		p.closeConn(conn)
+		return
+	}
+	p.idle.PushBack(conn)
+	p.mu.Unlock()
+}
+
+func (p *ConnectionPool) Stats() PoolStats {
+	return PoolStats{
+		Hits:       atomic.LoadUint64(&p.hits),
+		Misses:     atomic.LoadUint64(&p.misses),
+		DialErrors: atomic.LoadUint64(&p.dialErrors),
+		Evictions:  atomic.LoadUint64(&p.evictions),
+		IdleConns:  p.Len(),
+		OpenConns:  int(atomic.LoadInt64(&p.open)),
+	}
+}
+
+// Close closes all idle connections, connections checked out are closed on Release
+func (p *ConnectionPool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	var conns []*PoolConn
+	for elm := p.idle.Front(); elm != nil; elm = elm.Next() {
+		conns = append(conns, elm.Value.(*PoolConn))
+	}
+	p.idle.Init()
+	p.mu.Unlock()
+	for _, conn := range conns {
+		p.closeConn(conn)
+	}
+	return nil
+}
+
+// ConnClosed reports whether an idle connection was closed by the peer or has unread data.
+// Either way it cannot be reused for a new request.
+func ConnClosed(conn *PoolConn) bool {
+	if conn.hasBufferedData() {
+		return true
+	}
+	conn.limiter.reset(0)
+	if err := conn.SetReadDeadline(time.Now().Add(healthCheckTimeout)); err != nil {
+		return true
+	}
+	_, err := conn.Reader.Peek(1)
+	if err == nil {
+		// unsolicited data, the framing is no longer trustworthy
+		return true
+	}
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		return true
+	}
+	return conn.SetReadDeadline(time.Time{}) != nil
 }
@@ -0,0 +1,119 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	asserting "github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+// lineServer answers every received line with output, closing the connection after each response if closeAfter
+func lineServer(t *testing.T, output string, closeAfter bool) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveLines(l, output, closeAfter)
+	return l
+}
+
+// serveLines serves l like lineServer
+func serveLines(l net.Listener, output string, closeAfter bool) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				if _, err := r.ReadBytes('\n'); err != nil {
+					return
+				}
+				if _, err := conn.Write([]byte(output + "\n")); err != nil {
+					return
+				}
+				if closeAfter {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestTCPClientReuseConnection(t *testing.T) {
+	assert := asserting.New(t)
+	l := lineServer(t, `{"id":1,"jsonrpc":"2.0","result":"1934"}`, false)
+	defer l.Close()
+	cli := NewTCPClient(l.Addr().String())
+	defer cli.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+		assert.NoError(err)
+		n, err := resp.GetInt64()
+		assert.NoError(err)
+		assert.Equal(int64(1934), n)
+	}
+	stats := cli.PoolStats()
+	assert.Equal(uint64(1), stats.Misses)
+	assert.Equal(uint64(2), stats.Hits)
+	assert.Equal(1, stats.IdleConns)
+	assert.Equal(1, stats.OpenConns)
+}
+
+func TestTCPClientEvictClosedConnection(t *testing.T) {
+	assert := asserting.New(t)
+	l := lineServer(t, `{"id":1,"jsonrpc":"2.0","result":"1934"}`, true)
+	defer l.Close()
+	cli := NewTCPClient(l.Addr().String())
+	defer cli.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+		assert.NoError(err)
+		// let the server close its side
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := cli.PoolStats()
+	assert.Equal(uint64(0), stats.Hits)
+	assert.Equal(uint64(2), stats.Misses)
+	assert.Equal(uint64(1), stats.Evictions)
+}
+
+func TestConnectionPoolLimits(t *testing.T) {
+	assert := asserting.New(t)
+	l := lineServer(t, `{}`, false)
+	defer l.Close()
+	var d net.Dialer
+	pool := NewConnPool(func(ctx context.Context) (net.Conn, error) {
+		return d.DialContext(ctx, "tcp", l.Addr().String())
+	}, 1, 20*time.Millisecond)
+
+	c1, err := pool.Get(context.Background())
+	assert.NoError(err)
+	c2, err := pool.Get(context.Background())
+	assert.NoError(err)
+	c1.Release()
+	c2.Release()
+	// only MaxIdle connections are kept
+	assert.Equal(1, pool.Len())
+	assert.Equal(1, pool.Stats().OpenConns)
+
+	// idle connection expired
+	time.Sleep(30 * time.Millisecond)
+	c3, err := pool.Get(context.Background())
+	assert.NoError(err)
+	assert.Equal(uint64(1), pool.Stats().Evictions)
+	c3.MarkBroken()
+	c3.Release()
+	assert.Equal(0, pool.Len())
+	assert.Equal(0, pool.Stats().OpenConns)
+
+	assert.NoError(pool.Close())
+	_, err = pool.Get(context.Background())
+	assert.Equal(ErrPoolClosed, err)
+}
@@ -0,0 +1,148 @@
+package jsonrpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+type RetryPolicy struct {
+	// total attempts of a call, 0 or 1 means no retry
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// backoff is multiplied by Multiplier after every attempt, 2 if not set
+	Multiplier float64
+}
+
+// Backoff returns the wait time before the retry-th retry, starts from 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(backoff)
+}
+
+// IsRetryable reports whether err means the endpoint is unavailable,
+// RPC errors and client side http errors are answers of a working server.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	switch e := errors.Cause(err).(type) {
+	case RPCError, *RPCError:
+		return false
+	case HTTPError:
+		return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+type callerContextKey struct{}
+
+// WithCallerTimeout returns ctx with a deadline set by the caller after timeout, e.g. the scrape timeout.
+// Calls past it are aborted by the caller and not counted by the circuit breaker,
+// while calls past a deadline of their own, e.g. --rpc-timeout, are failures of a hung endpoint
+func WithCallerTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return context.WithValue(ctx, callerContextKey{}, ctx), cancel
+}
+
+// abortedByCaller reports whether a call with ctx ending with err was canceled, or past a deadline of WithCallerTimeout,
+// either tells nothing about the endpoint
+func abortedByCaller(ctx context.Context, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled) {
+		return true
+	}
+	caller, ok := ctx.Value(callerContextKey{}).(context.Context)
+	return ok && caller.Err() != nil
+}
+
+// ResilientClient retries failed calls by a RetryPolicy, and skips calls while the CircuitBreaker is open
+type ResilientClient struct {
+	Client
+	policy  RetryPolicy
+	breaker *CircuitBreaker
+}
+
+func NewResilientClient(cli Client, policy RetryPolicy, breaker *CircuitBreaker) *ResilientClient {
+	if breaker == nil {
+		breaker = NewCircuitBreaker(cli.Address(), 0, 0)
+	}
+	return &ResilientClient{Client: cli, policy: policy, breaker: breaker}
+}
+
+func (c *ResilientClient) Breaker() *CircuitBreaker {
+	return c.breaker
+}
+
+func (c *ResilientClient) do(ctx context.Context, call func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.Allow(); err != nil {
+			return errors.Wrap(err, c.breaker.Name)
+		}
+		err = call()
+		if abortedByCaller(ctx, err) {
+			c.breaker.Abort()
+			return err
+		}
+		if !IsRetryable(err) {
+			// a result, or an error answered by the server
+			c.breaker.Success()
+			return err
+		}
+		c.breaker.Failure()
+		if attempt >= c.policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		backoff := c.policy.Backoff(attempt)
+		log.WithError(err).WithField("endpoint", c.Address()).Debugf("retry in %s", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (c *ResilientClient) Call(request *Request) (*Response, error) {
+	return c.CallContext(context.Background(), request)
+}
+
+func (c *ResilientClient) CallContext(ctx context.Context, request *Request) (*Response, error) {
+	var resp *Response
+	err := c.do(ctx, func() error {
+		var err error
+		resp, err = c.Client.CallContext(ctx, request)
+		return err
+	})
+	return resp, err
+}
+
+func (c *ResilientClient) CallBatch(requests ...*Request) (*BatchResponse, error) {
+	return c.CallBatchContext(context.Background(), requests...)
+}
+
+func (c *ResilientClient) CallBatchContext(ctx context.Context, requests ...*Request) (*BatchResponse, error) {
+	var batch *BatchResponse
+	err := c.do(ctx, func() error {
+		var err error
+		batch, err = c.Client.CallBatchContext(ctx, requests...)
+		return err
+	})
+	return batch, err
+}
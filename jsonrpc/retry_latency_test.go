@@ -0,0 +1,44 @@
+package jsonrpc_test
+
+import (
+	"context"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpctest"
+	"testing"
+	"time"
+)
+
+func TestResilientClientDeadline(t *testing.T) {
+	assert := asserting.New(t)
+	server := jsonrpctest.NewHTTPServer()
+	defer server.Close()
+	server.SetResult("GetNetworkId", "1")
+	server.SetLatency(200 * time.Millisecond)
+
+	call := func(cli *jsonrpc.ResilientClient, ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := cli.CallContext(ctx, jsonrpc.NewRequest("GetNetworkId", nil))
+		assert.Error(err)
+	}
+	policy := jsonrpc.RetryPolicy{MaxAttempts: 1}
+
+	// a hung endpoint fails calls past their own deadline, and opens the breaker
+	cli := jsonrpc.NewResilientClient(jsonrpc.NewHTTPClient(server.URL, jsonrpc.HTTPOptions{}), policy,
+		jsonrpc.NewCircuitBreaker(server.URL, 2, time.Minute))
+	call(cli, context.Background())
+	assert.Equal(jsonrpc.BreakerClosed, cli.Breaker().State())
+	call(cli, context.Background())
+	assert.Equal(jsonrpc.BreakerOpen, cli.Breaker().State())
+
+	// calls stopped at the deadline of the caller are not counted
+	cli = jsonrpc.NewResilientClient(jsonrpc.NewHTTPClient(server.URL, jsonrpc.HTTPOptions{}), policy,
+		jsonrpc.NewCircuitBreaker(server.URL, 2, time.Minute))
+	for i := 0; i < 3; i++ {
+		ctx, cancel := jsonrpc.WithCallerTimeout(context.Background(), 10*time.Millisecond)
+		call(cli, ctx)
+		cancel()
+	}
+	assert.Equal(jsonrpc.BreakerClosed, cli.Breaker().State())
+}
@@ -0,0 +1,141 @@
+package jsonrpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	assert := asserting.New(t)
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(400*time.Millisecond, policy.Backoff(3))
+	assert.Equal(time.Second, policy.Backoff(10))
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert := asserting.New(t)
+	assert.False(IsRetryable(nil))
+	assert.False(IsRetryable(RPCError{Code: -32601, Message: "METHOD_NOT_FOUND"}))
+	assert.False(IsRetryable(HTTPError{StatusCode: http.StatusUnauthorized}))
+	assert.False(IsRetryable(errors.Wrap(ErrCircuitOpen, "endpoint")))
+	assert.True(IsRetryable(HTTPError{StatusCode: http.StatusBadGateway}))
+	assert.True(IsRetryable(HTTPError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(IsRetryable(errors.Wrap(ErrConnectionClosed, "read")))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	assert := asserting.New(t)
+	now := time.Now()
+	b := NewCircuitBreaker("test", 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	assert.NoError(b.Allow())
+	b.Failure()
+	assert.Equal(BreakerClosed, b.State())
+	b.Failure()
+	assert.Equal(BreakerOpen, b.State())
+	assert.Equal(ErrCircuitOpen, b.Allow())
+
+	// after cooldown only one trial call is allowed
+	now = now.Add(time.Minute)
+	assert.Equal(BreakerHalfOpen, b.State())
+	assert.NoError(b.Allow())
+	assert.Equal(ErrCircuitOpen, b.Allow())
+	b.Failure()
+	assert.Equal(BreakerOpen, b.State())
+
+	now = now.Add(time.Minute)
+	assert.NoError(b.Allow())
+	b.Success()
+	assert.Equal(BreakerClosed, b.State())
+	assert.NoError(b.Allow())
+
+	disabled := NewCircuitBreaker("disabled", 0, time.Minute)
+	for i := 0; i < 10; i++ {
+		disabled.Failure()
+	}
+	assert.NoError(disabled.Allow())
+}
+
+func TestResilientClient(t *testing.T) {
+	assert := asserting.New(t)
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","result":"1"}`))
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	cli := NewResilientClient(NewHTTPClient(server.URL, HTTPOptions{}), policy, NewCircuitBreaker(server.URL, 3, time.Minute))
+	_, err := cli.Call(NewRequest("GetNetworkId", nil))
+	assert.NoError(err)
+	assert.Equal(int64(3), atomic.LoadInt64(&calls))
+	assert.Equal(BreakerClosed, cli.Breaker().State())
+
+	// 2 failures with no retry left, then the breaker opens on the third
+	policy.MaxAttempts = 1
+	cli = NewResilientClient(NewHTTPClient(server.URL, HTTPOptions{}), policy, NewCircuitBreaker(server.URL, 2, time.Minute))
+	for i := 0; i < 2; i++ {
+		_, err = cli.Call(NewRequest("GetNetworkId", nil))
+		assert.Error(err)
+	}
+	assert.Equal(BreakerOpen, cli.Breaker().State())
+	_, err = cli.Call(NewRequest("GetNetworkId", nil))
+	assert.True(errors.Is(err, ErrCircuitOpen))
+	assert.Equal(int64(5), atomic.LoadInt64(&calls))
+}
+
+func TestResilientClientAborted(t *testing.T) {
+	assert := asserting.New(t)
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&calls, 1) == 2 {
+			_, _ = w.Write([]byte(`{"id":1,"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cli := NewResilientClient(NewHTTPClient(server.URL, HTTPOptions{}), RetryPolicy{MaxAttempts: 1}, NewCircuitBreaker(server.URL, 2, time.Minute))
+	_, err := cli.Call(NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	// an rpc error is an answer of the server, and resets failures
+	resp, err := cli.Call(NewRequest("GetNetworkId", nil))
+	assert.NoError(err)
+	assert.Error(resp.Err())
+	_, err = cli.Call(NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	assert.Equal(BreakerClosed, cli.Breaker().State())
+
+	// a canceled call does not reset failures
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cli.CallContext(ctx, NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	assert.Equal(BreakerClosed, cli.Breaker().State())
+	_, err = cli.Call(NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	assert.Equal(BreakerOpen, cli.Breaker().State())
+
+	// a canceled trial call lets another trial through
+	cli.Breaker().now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = cli.CallContext(ctx, NewRequest("GetNetworkId", nil))
+	assert.False(errors.Is(err, ErrCircuitOpen))
+	assert.Equal(BreakerHalfOpen, cli.Breaker().State())
+	_, err = cli.Call(NewRequest("GetNetworkId", nil))
+	assert.False(errors.Is(err, ErrCircuitOpen))
+	assert.Equal(BreakerOpen, cli.Breaker().State())
+}
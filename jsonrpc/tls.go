@@ -0,0 +1,49 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"io/ioutil"
+)
+
+type TLSOptions struct {
+	// PEM encoded CA certificates to verify the server, system roots are used if empty
+	CAFile string
+	// PEM encoded client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// overrides the server name used to verify the server certificate
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// Config builds the tls.Config from files
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to read CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificate found in CA file %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("client certificate and key should be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "fail to load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,165 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	asserting "github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	tlsCert tls.Certificate
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{name},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		tlsCert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+// writePEM writes certificate and key of c to dir, returns the file paths
+func (c *testCert) writePEM(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := ioutil.WriteFile(certFile, certPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// tlsLineServer answers every line received with output over TLS
+func tlsLineServer(t *testing.T, cfg *tls.Config, output string) net.Listener {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if _, err := r.ReadBytes('\n'); err != nil {
+						return
+					}
+					if _, err := conn.Write([]byte(output + "\n")); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func TestTLSClient(t *testing.T) {
+	assert := asserting.New(t)
+	dir, err := ioutil.TempDir("", "jsonrpc-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", nil, true)
+	server := newTestCert(t, "status.zilliqa.local", ca, false)
+	client := newTestCert(t, "exporter", ca, false)
+	caFile, _ := ca.writePEM(t, dir, "ca")
+	certFile, keyFile := client.writePEM(t, dir, "client")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	l := tlsLineServer(t, &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}, `{"id":1,"jsonrpc":"2.0","result":"1934"}`)
+	defer l.Close()
+
+	call := func(opts TLSOptions) error {
+		cfg, err := opts.Config()
+		if err != nil {
+			return err
+		}
+		tcpOpts := DefaultTCPOptions()
+		tcpOpts.TLSConfig = cfg
+		cli := NewTCPClientWithOptions(l.Addr().String(), tcpOpts)
+		defer cli.Close()
+		resp, err := cli.Call(NewRequest("GetCurrentMiniEpoch", nil))
+		if err != nil {
+			return err
+		}
+		n, err := resp.GetInt64()
+		assert.Equal(int64(1934), n)
+		return err
+	}
+
+	// mutual TLS with server name override
+	assert.NoError(call(TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "status.zilliqa.local"}))
+	// server certificate not valid for the name
+	assert.Error(call(TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "other.zilliqa.local"}))
+	// unknown authority
+	assert.Error(call(TLSOptions{CertFile: certFile, KeyFile: keyFile, ServerName: "status.zilliqa.local"}))
+	assert.NoError(call(TLSOptions{CertFile: certFile, KeyFile: keyFile, InsecureSkipVerify: true}))
+	// client certificate required
+	assert.Error(call(TLSOptions{CAFile: caFile, ServerName: "status.zilliqa.local"}))
+
+	_, err = TLSOptions{CertFile: certFile}.Config()
+	assert.Error(err)
+	_, err = TLSOptions{CAFile: filepath.Join(dir, "missing.crt")}.Config()
+	assert.Error(err)
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/pkg/errors"
 	"math/big"
 	"reflect"
-	"strings"
 )
 
 type Request struct {
@@ -19,6 +18,11 @@ func NewRequest(method string, params interface{}) *Request {
 	return &Request{Method: method, Params: params}
 }
 
+// ID returns the id assigned to the request when it was sent
+func (r *Request) ID() int64 {
+	return r.id
+}
+
 func (r Request) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -37,29 +41,6 @@ func parseResponse(raw []byte) (*Response, error) {
 	return resp, err
 }
 
-func parseBatchResponse(raw []byte) ([]*Response, error) {
-	var resps []*Response
-	err := json.Unmarshal(raw, &resps)
-	if err != nil {
-		var errs []error
-		for _, resp := range resps {
-			if e := resp.check(); e != nil {
-				errs = append(errs, e)
-			}
-		}
-		var errStrings []string
-		for _, e := range errs {
-			errStrings = append(errStrings, e.Error())
-		}
-		if len(errs) == 1 {
-			err = errors.Wrap(errs[0], "jsonrpc response parse error")
-		} else if len(errs) >= 1 {
-			err = errors.New("jsonrpc response parse error:" + strings.Join(errStrings, ";"))
-		}
-	}
-	return resps, err
-}
-
 type Response struct {
 	Version string          `json:"jsonrpc,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
@@ -67,6 +48,38 @@ type Response struct {
 	Id      int64           `json:"id,omitempty"`
 }
 
+// UnmarshalJSON accepts id as number or numeric string, as some servers echo it as string
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type response Response
+	aux := struct {
+		*response
+		Id json.RawMessage `json:"id,omitempty"`
+	}{response: (*response)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Id = 0
+	if len(aux.Id) == 0 || string(aux.Id) == "null" {
+		return nil
+	}
+	var id json.Number
+	if aux.Id[0] == '"' {
+		var s string
+		if err := json.Unmarshal(aux.Id, &s); err != nil {
+			return err
+		}
+		id = json.Number(s)
+	} else if err := json.Unmarshal(aux.Id, &id); err != nil {
+		return err
+	}
+	n, err := id.Int64()
+	if err != nil {
+		return errors.Wrap(err, "unsupported response id "+string(aux.Id))
+	}
+	r.Id = n
+	return nil
+}
+
 // TODO: response validation
 func (r Response) check() error {
 	return nil
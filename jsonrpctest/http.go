@@ -0,0 +1,69 @@
+package jsonrpctest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// HTTPServer serves JsonRPC over HTTP, like the lookup API
+type HTTPServer struct {
+	*Server
+	HTTP *httptest.Server
+	URL  string
+
+	mu     sync.Mutex
+	status int
+}
+
+// NewHTTPServer starts a HTTPServer on a random port of loopback interface, the endpoint is URL
+func NewHTTPServer() *HTTPServer {
+	s := &HTTPServer{Server: NewServer()}
+	s.HTTP = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.HTTP.URL
+	return s
+}
+
+// SetStatus answers every request with an empty body of status code, 0 to stop
+func (s *HTTPServer) SetStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+func (s *HTTPServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resp, ok := s.Handle(payload)
+	if !ok {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				_ = conn.Close()
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// CloseClientConnections closes all open connections
+func (s *HTTPServer) CloseClientConnections() {
+	s.HTTP.CloseClientConnections()
+}
+
+func (s *HTTPServer) Close() {
+	s.HTTP.Close()
+}
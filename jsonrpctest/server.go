@@ -0,0 +1,198 @@
+// Package jsonrpctest provides scriptable in-process JsonRPC servers, over TCP like the admin
+// (status) server of zilliqa and over HTTP like the lookup API, for tests of clients and collectors.
+package jsonrpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"sync"
+	"time"
+)
+
+// HandlerFunc answers the call-th (starts from 1) call of a method, a RPCError becomes an error response
+type HandlerFunc func(call int, params json.RawMessage) (interface{}, error)
+
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Version string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *jsonrpc.RPCError `json:"error,omitempty"`
+}
+
+// Server dispatches JsonRPC requests to scripted handlers, methods not scripted answer MethodNotFound.
+// It is embedded by TCPServer and HTTPServer.
+type Server struct {
+	mu        sync.Mutex
+	handlers  map[string]HandlerFunc
+	calls     map[string]int
+	latency   time.Duration
+	drops     int
+	malformed []byte
+}
+
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]HandlerFunc), calls: make(map[string]int)}
+}
+
+// SetHandler scripts a method with a handler
+func (s *Server) SetHandler(method string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// SetResult answers every call of method with result
+func (s *Server) SetResult(method string, result interface{}) {
+	s.SetHandler(method, func(int, json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// SetResults answers calls of method with results in order, the last one is repeated
+func (s *Server) SetResults(method string, results ...interface{}) {
+	s.SetHandler(method, func(call int, _ json.RawMessage) (interface{}, error) {
+		if call > len(results) {
+			call = len(results)
+		}
+		return results[call-1], nil
+	})
+}
+
+// SetError answers every call of method with err
+func (s *Server) SetError(method string, err jsonrpc.RPCError) {
+	s.SetHandler(method, func(int, json.RawMessage) (interface{}, error) {
+		return nil, err
+	})
+}
+
+// SetLatency delays every response
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// DropConnections closes the connection instead of answering the next n payloads
+func (s *Server) DropConnections(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drops = n
+}
+
+// SetMalformed answers every payload with the raw bytes of payload, empty to stop
+func (s *Server) SetMalformed(payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if payload == "" {
+		s.malformed = nil
+		return
+	}
+	s.malformed = []byte(payload)
+}
+
+// Calls returns how many times method was called
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Reset removes all scripts and call counts
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = make(map[string]HandlerFunc)
+	s.calls = make(map[string]int)
+	s.latency = 0
+	s.drops = 0
+	s.malformed = nil
+}
+
+// Handle returns the response to a single or batch payload, ok is false if the connection should be dropped
+func (s *Server) Handle(payload []byte) (resp []byte, ok bool) {
+	s.mu.Lock()
+	latency := s.latency
+	drop := s.drops > 0
+	if drop {
+		s.drops--
+	}
+	malformed := s.malformed
+	s.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	if drop {
+		return nil, false
+	}
+	if malformed != nil {
+		return malformed, true
+	}
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) > 0 && payload[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(payload, &reqs); err != nil {
+			return s.marshal(s.parseError()), true
+		}
+		resps := make([]*response, 0, len(reqs))
+		for _, req := range reqs {
+			resps = append(resps, s.dispatch(req))
+		}
+		return s.marshal(resps), true
+	}
+	var req request
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return s.marshal(s.parseError()), true
+	}
+	return s.marshal(s.dispatch(req)), true
+}
+
+func (s *Server) parseError() *response {
+	rpcErr := jsonrpc.ParserError
+	return &response{Version: "2.0", ID: json.RawMessage("null"), Error: &rpcErr}
+}
+
+func (s *Server) dispatch(req request) *response {
+	resp := &response{Version: "2.0", ID: req.ID}
+	if len(resp.ID) == 0 {
+		resp.ID = json.RawMessage("null")
+	}
+	s.mu.Lock()
+	handler, ok := s.handlers[req.Method]
+	s.calls[req.Method]++
+	call := s.calls[req.Method]
+	s.mu.Unlock()
+	if !ok {
+		rpcErr := jsonrpc.MethodNotFound
+		resp.Error = &rpcErr
+		return resp
+	}
+	result, err := handler(call, req.Params)
+	switch e := err.(type) {
+	case nil:
+		resp.Result = result
+	case jsonrpc.RPCError:
+		resp.Error = &e
+	case *jsonrpc.RPCError:
+		resp.Error = e
+	default:
+		resp.Error = &jsonrpc.RPCError{Code: jsonrpc.InternalError.Code, Message: err.Error()}
+	}
+	return resp
+}
+
+func (s *Server) marshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,81 @@
+package jsonrpctest
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	asserting "github.com/stretchr/testify/assert"
+	"github.com/zilliqa/zilliqa-exporter/jsonrpc"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTCPServer(t *testing.T) {
+	assert := asserting.New(t)
+	s := NewTCPServer()
+	defer s.Close()
+	s.SetResult("GetCurrentDSEpoch", "19")
+	s.SetResults("GetCurrentMiniEpoch", "1934", "1935")
+	cli := jsonrpc.NewTCPClient(s.Addr())
+	defer cli.Close()
+
+	for _, want := range []int64{1934, 1935, 1935} {
+		resp, err := cli.Call(jsonrpc.NewRequest("GetCurrentMiniEpoch", nil))
+		assert.NoError(err)
+		n, err := resp.GetInt64()
+		assert.NoError(err)
+		assert.Equal(want, n)
+	}
+	assert.Equal(3, s.Calls("GetCurrentMiniEpoch"))
+
+	dsEpochReq := jsonrpc.NewRequest("GetCurrentDSEpoch", nil)
+	stateReq := jsonrpc.NewRequest("GetNodeState", nil)
+	batch, err := cli.CallBatch(dsEpochReq, stateReq)
+	assert.NoError(err)
+	resp, err := batch.Get(dsEpochReq)
+	assert.NoError(err)
+	n, err := resp.GetInt64()
+	assert.NoError(err)
+	assert.Equal(int64(19), n)
+	assert.True(errors.Is(batch.Err(stateReq), jsonrpc.MethodNotFound))
+
+	s.DropConnections(1)
+	_, err = cli.Call(jsonrpc.NewRequest("GetCurrentDSEpoch", nil))
+	assert.True(errors.Is(err, jsonrpc.ErrConnectionClosed))
+	_, err = cli.Call(jsonrpc.NewRequest("GetCurrentDSEpoch", nil))
+	assert.NoError(err)
+
+	s.SetMalformed(`{"id":1,"result":]}`)
+	_, err = cli.Call(jsonrpc.NewRequest("GetCurrentDSEpoch", nil))
+	assert.True(errors.Is(err, jsonrpc.ErrResponseMalformed))
+	s.SetMalformed("")
+
+	s.SetLatency(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = cli.CallContext(ctx, jsonrpc.NewRequest("GetCurrentDSEpoch", nil))
+	assert.Equal(jsonrpc.OutcomeTimeout, jsonrpc.Outcome(err))
+}
+
+func TestHTTPServer(t *testing.T) {
+	assert := asserting.New(t)
+	s := NewHTTPServer()
+	defer s.Close()
+	s.SetError("GetNetworkId", jsonrpc.InternalError)
+	cli := jsonrpc.NewHTTPClient(s.URL, jsonrpc.HTTPOptions{})
+
+	resp, err := cli.Call(jsonrpc.NewRequest("GetNetworkId", nil))
+	assert.NoError(err)
+	assert.True(errors.Is(resp.Err(), jsonrpc.InternalError))
+
+	s.SetStatus(http.StatusTooManyRequests)
+	_, err = cli.Call(jsonrpc.NewRequest("GetNetworkId", nil))
+	assert.Equal("http_429", jsonrpc.Outcome(err))
+	s.SetStatus(0)
+
+	s.DropConnections(1)
+	_, err = cli.Call(jsonrpc.NewRequest("GetNetworkId", nil))
+	assert.Error(err)
+	// rejected and dropped payloads are not dispatched
+	assert.Equal(1, s.Calls("GetNetworkId"))
+}
@@ -0,0 +1,94 @@
+package jsonrpctest
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+)
+
+// TCPServer serves newline terminated JsonRPC payloads over TCP, like the admin (status) server of zilliqa
+type TCPServer struct {
+	*Server
+	Listener net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewTCPServer starts a TCPServer on a random port of loopback interface, it panics if fails to listen
+func NewTCPServer() *TCPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("jsonrpctest: failed to listen on a port: " + err.Error())
+	}
+	return NewTCPServerWithListener(l)
+}
+
+// NewTCPServerWithListener serves on l, e.g. a tls or unix socket listener
+func NewTCPServerWithListener(l net.Listener) *TCPServer {
+	s := &TCPServer{Server: NewServer(), Listener: l, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr returns the address to dial
+func (s *TCPServer) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+func (s *TCPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *TCPServer) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+	decoder := json.NewDecoder(conn)
+	for {
+		var payload json.RawMessage
+		if err := decoder.Decode(&payload); err != nil {
+			return
+		}
+		resp, ok := s.Handle(payload)
+		if !ok {
+			return
+		}
+		if _, err := conn.Write(append(resp, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+// CloseClientConnections closes all open connections, as a restarted server would
+func (s *TCPServer) CloseClientConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// Close stops listening, closes all connections and waits for them to finish
+func (s *TCPServer) Close() {
+	_ = s.Listener.Close()
+	s.CloseClientConnections()
+	s.wg.Wait()
+}
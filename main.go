@@ -11,7 +11,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/urfave/negroni"
+	"github.com/zilliqa/zilliqa-exporter/admincmd"
 	"github.com/zilliqa/zilliqa-exporter/collector"
+	"github.com/zilliqa/zilliqa-exporter/gateway"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -21,6 +23,7 @@ import (
 )
 
 var options = &collector.Options{}
+var gatewayOptions = &gateway.Options{}
 var listen string
 var logLevel string
 var printVersion bool
@@ -78,12 +81,18 @@ func initlog() {
 func main() {
 	cmd.SilenceErrors = false
 	cmd.SilenceUsage = true
-	options.BindFlags(cmd.Flags())
+	// shared with admin subcommands
+	options.BindConnectionFlags(cmd.PersistentFlags())
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level")
+	options.BindServerFlags(cmd.Flags())
+	gatewayOptions.BindFlags(cmd.Flags())
 	cmd.Flags().StringVarP(&listen, "listen", "l", "0.0.0.0:8080", "listen address of exporter")
-	cmd.Flags().StringVar(&logLevel, "log-level", "info", "log level")
 	cmd.Flags().BoolVarP(&printVersion, "version", "v", false, "print version info")
+	cmd.AddCommand(admincmd.NewCommand(options))
 	cobra.OnInitialize(initlog)
-	_ = cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 
 }
 
@@ -123,28 +132,107 @@ func serve(listen string) {
 	_ = json.Unmarshal(constantJson, &constantsMap)
 	log.WithFields(constantsMap).Info("got constants")
 
+	config, err := options.LoadConfig()
+	if err != nil {
+		log.WithError(err).Fatal("fail to load config")
+	}
+
+	// collectors calling the node are collected with the deadline of every scrape,
+	// or served from snapshots refreshed in background with --poll-interval
+	scrapeHandler := collector.NewScrapeHandler(constants, prometheus.DefaultGatherer)
+	var pollers []*collector.PollingCollector
+	defer func() {
+		for _, p := range pollers {
+			p.Stop()
+		}
+	}()
+	registerNodeCollector := func(name string, c collector.ContextCollector) {
+		if options.PollInterval() <= 0 {
+			scrapeHandler.Register(name, c)
+			return
+		}
+		p := collector.NewPollingCollector(constants, name, c)
+		go p.Start()
+		pollers = append(pollers, p)
+		prometheus.MustRegister(p)
+	}
+
+	rpcMetrics := collector.NewRPCMetrics(constants)
+	options.SetRPCObserver(rpcMetrics)
+	prometheus.MustRegister(rpcMetrics)
+	registerNodeCollector("reference", collector.NewReferenceCollector(constants))
+
 	if !options.NotCollectAPI {
-		prometheus.MustRegister(collector.NewAPICollector(constants))
+		registerNodeCollector("api", collector.NewAPICollector(constants))
+		txBlockFollower := collector.NewTxBlockFollower(constants)
+		go txBlockFollower.Start()
+		defer txBlockFollower.Stop()
+		prometheus.MustRegister(txBlockFollower)
+		dsBlockFollower := collector.NewDSBlockFollower(constants)
+		go dsBlockFollower.Start()
+		defer dsBlockFollower.Stop()
+		prometheus.MustRegister(dsBlockFollower)
+		contracts := collector.NewScheduledCollector(constants, config.Contracts)
+		contracts.Start()
+		defer contracts.Stop()
+		prometheus.MustRegister(contracts)
+		accounts := collector.NewAccountWatcher(constants, config.Accounts)
+		go accounts.Start()
+		defer accounts.Stop()
+		prometheus.MustRegister(accounts)
+		prober := collector.NewAPIProber(constants, config.Probes)
+		prober.Start()
+		defer prober.Stop()
+		prometheus.MustRegister(prober)
 	} else {
 		log.Info("Not collecting info from API server")
 	}
 	if !options.NotCollectAdmin {
-		prometheus.MustRegister(collector.NewAdminCollector(constants))
+		registerNodeCollector("admin", collector.NewAdminCollector(constants))
+		sampler := collector.NewNodeStateSampler(constants)
+		go sampler.Start()
+		defer sampler.Stop()
+		prometheus.MustRegister(sampler)
+		reconciler := collector.NewBlacklistReconciler(constants)
+		go reconciler.Start()
+		defer reconciler.Stop()
+		prometheus.MustRegister(reconciler)
 	} else {
 		log.Info("Not collecting info from Admin(status) server")
 	}
 	if !options.NotCollectProcessInfo {
-		prometheus.MustRegister(collector.NewProcessInfoCollector(constants))
+		registerNodeCollector("process_info", collector.NewProcessInfoCollector(constants))
 	} else {
 		log.Info("Not collecting info from Zilliqa Process")
 	}
 
 	router := mux.NewRouter()
-	router.Handle("/metrics", promhttp.Handler())
+	router.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, scrapeHandler))
+	if options.EnableProbe {
+		if len(config.Modules) == 0 {
+			log.Fatal("fail to enable /probe: no modules in config")
+		}
+		router.Handle("/probe", collector.NewProbeHandler(options, config.Modules))
+		log.WithField("modules", len(config.Modules)).Info("/probe enabled")
+	}
 	router.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
 		panic("panic test")
 	})
 	router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
+	if gatewayOptions.Enabled {
+		tokens, err := gatewayOptions.Tokens()
+		if err != nil {
+			log.WithError(err).Fatal("fail to enable admin gateway")
+		}
+		audit, err := gatewayOptions.AuditLogger()
+		if err != nil {
+			log.WithError(err).Fatal("fail to enable admin gateway")
+		}
+		adminGateway := gateway.NewAdminGateway(options.GetAdminOperationClient(), tokens, options.RPCTimeout(), audit)
+		prometheus.MustRegister(adminGateway)
+		adminGateway.Register(router)
+		log.WithFields(gatewayOptions.ToMap()).Info("admin gateway enabled")
+	}
 
 	n := negroni.New()
 	recovery := &negroni.Recovery{
@@ -17,7 +17,8 @@ const AWSMetadataIP = "169.254.169.254"
 const AWSMetadataUrl = "http://169.254.169.254/latest/meta-data"
 
 func GetHTTPText(url string) string {
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		log.WithField("url", url).WithError(err).Error("fail to build request")